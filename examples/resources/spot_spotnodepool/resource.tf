@@ -8,3 +8,15 @@ resource "spot_spotnodepool" "example" {
     max_nodes = 4
   }
 }
+
+# Bids 10% above the current market price of the server class, at most 0.01 USD per hour.
+resource "spot_spotnodepool" "market" {
+  cloudspace_name      = "example"
+  name_prefix          = "market-"
+  server_class         = "gp.vs1.small-dfw"
+  desired_server_count = 2
+  bid_strategy = {
+    market_plus_percent = 10
+    max_bid_price       = 0.01
+  }
+}
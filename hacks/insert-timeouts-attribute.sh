@@ -2,10 +2,18 @@
 
 # This script is a temporary fix to the https://github.com/hashicorp/terraform-plugin-codegen-framework/issues/143 
 
-FILE="internal/provider/resource_cloudspace/cloudspace_resource_gen.go"
+# insert_timeouts adds the timeouts attribute with the given operations before the wait_for_nodes attribute
+insert_timeouts() {
+	FILE="$1"
+	OPTS="$2"
 
-sed -i '/"github.com\/hashicorp\/terraform-plugin-framework\/types"/i\\t"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"' "$FILE"
+	sed -i '/"github.com\/hashicorp\/terraform-plugin-framework\/types"/i\\t"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"' "$FILE"
 
-sed -i '/"wait_until_ready": schema.BoolAttribute{/i\\t\t\t"timeouts": timeouts.Attributes(ctx, timeouts.Opts{\n\t\t\t\tCreate: true,\n\t\t\t}),' "$FILE"
+	sed -i "/\"wait_for_nodes\": schema.BoolAttribute{/i\\\\t\\t\\t\"timeouts\": timeouts.Attributes(ctx, timeouts.Opts{\\n${OPTS}\\t\\t\\t})," "$FILE"
 
-sed -i '/WaitUntilReady[[:space:]]*types\.Bool/i\\tTimeouts timeouts.Value `tfsdk:"timeouts"`' "$FILE"
+	sed -i '/WaitForNodes[[:space:]]*types\.Bool/i\\tTimeouts timeouts.Value `tfsdk:"timeouts"`' "$FILE"
+}
+
+insert_timeouts "internal/provider/resource_cloudspace/cloudspace_resource_gen.go" '\t\t\t\tCreate: true,\n\t\t\t\tUpdate: true,\n\t\t\t\tDelete: true,\n'
+insert_timeouts "internal/provider/resource_spotnodepool/spotnodepool_resource_gen.go" '\t\t\t\tCreate: true,\n\t\t\t\tUpdate: true,\n'
+insert_timeouts "internal/provider/resource_ondemandnodepool/ondemandnodepool_resource_gen.go" '\t\t\t\tCreate: true,\n\t\t\t\tUpdate: true,\n'
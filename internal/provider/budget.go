@@ -0,0 +1,239 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/rackerlabs/terraform-provider-spot/internal/provider/provider_spot"
+)
+
+// spendBudget holds the limits of the budget block of the provider, a zero limit is not checked
+type spendBudget struct {
+	maxHourlySpend       float64
+	maxBidPricePerServer float64
+	// planned holds the node pools planned in the current run of terraform, shared by all the resources
+	planned *plannedSpend
+}
+
+// newSpendBudget returns the limits set in the budget block, limits which are not known are not checked
+func newSpendBudget(budget provider_spot.BudgetValue) spendBudget {
+	if budget.IsNull() || budget.IsUnknown() {
+		return spendBudget{}
+	}
+	return spendBudget{
+		maxHourlySpend:       budget.MaxHourlySpend.ValueFloat64(),
+		maxBidPricePerServer: budget.MaxBidPricePerServer.ValueFloat64(),
+		planned:              &plannedSpend{nodePools: map[string]plannedNodePool{}},
+	}
+}
+
+// plannedNodePool is a node pool being planned, checked against the budget along with the existing ones
+type plannedNodePool struct {
+	// kind is the prefix used in the breakdown, spotnodepool or ondemandnodepool
+	kind string
+	// name is the name of the existing node pool the planned one updates, replaces or destroys,
+	// it is empty for a node pool which is not created yet
+	name       string
+	hourlyCost float64
+}
+
+// plannedSpend holds the node pools planned in the same run of terraform, so the budget is checked against
+// the existing node pools plus all the planned changes. The provider is configured once per run, hence the
+// node pools planned so far are known when a node pool is planned, and the last one planned checks them all.
+type plannedSpend struct {
+	mu sync.Mutex
+	// nodePools holds the planned node pools by the kind and name of the node pool they update, replace or
+	// destroy. Node pools which are created get a key numbered in the order they are planned instead.
+	nodePools map[string]plannedNodePool
+	created   int
+}
+
+// add records the planned node pool and returns the node pools planned so far, by key
+func (s *plannedSpend) add(planned plannedNodePool) map[string]plannedNodePool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if planned.name != "" {
+		s.nodePools[planned.kind+"/"+planned.name] = planned
+	} else {
+		s.created++
+		s.nodePools[fmt.Sprintf("%s (new %d)", planned.kind, s.created)] = planned
+	}
+	nodePools := make(map[string]plannedNodePool, len(s.nodePools))
+	for key, nodePool := range s.nodePools {
+		nodePools[key] = nodePool
+	}
+	return nodePools
+}
+
+// markCreated records the name of a node pool created during apply, which is planned again right before,
+// so it is not counted both as planned and as existing by the node pools planned after it. Node pools of
+// the same kind and cost are interchangeable for the budget, any of them is renamed.
+func (s *plannedSpend) markCreated(kind string, name string, hourlyCost float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.nodePools[kind+"/"+name]; ok {
+		return
+	}
+	for key, nodePool := range s.nodePools {
+		if nodePool.kind == kind && nodePool.name == "" && nodePool.hourlyCost == hourlyCost {
+			delete(s.nodePools, key)
+			nodePool.name = name
+			s.nodePools[kind+"/"+name] = nodePool
+			return
+		}
+	}
+}
+
+// planNodePoolBudget checks the planned spend of a node pool against the budget, once its estimated cost
+// is known. Plans which do not increase the cost of the node pool are recorded but not checked, so node
+// pools can still be changed when the spend exceeds a budget lowered meanwhile. A destroyed node pool is
+// recorded without cost.
+//
+// Terraform plans a replacement again as a create, without prior state. The name of the replaced node pool
+// is kept in the private state of the plan, so the new node pool is counted in place of the replaced one.
+func planNodePoolBudget(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+	ngpcClient ngpc.Client, namespace string, budget spendBudget, kind string) {
+	if budget.maxHourlySpend == 0 || ngpcClient == nil {
+		return
+	}
+	var plannedCost, priorCost types.Float64
+	var name types.String
+	if req.Plan.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribName), &name)...)
+		if !resp.Diagnostics.HasError() {
+			budget.planned.add(plannedNodePool{kind: kind, name: name.ValueString()})
+		}
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(attribEstimatedHourlyCost), &plannedCost)...)
+	if req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(attribName), &name)...)
+		replacedName, diags := req.Private.GetKey(ctx, keyReplacedName)
+		resp.Diagnostics.Append(diags...)
+		if replacedName != nil {
+			var replaced string
+			if err := json.Unmarshal(replacedName, &replaced); err != nil {
+				resp.Diagnostics.AddError("Failed to check the budget", fmt.Sprintf("failed to read the name of the replaced %s: %s", kind, err.Error()))
+				return
+			}
+			name = types.StringValue(replaced)
+		}
+	} else {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribEstimatedHourlyCost), &priorCost)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribName), &name)...)
+		changed := plannedReplaceChanges(ctx, req, resp, nodePoolReplaceAttributes)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var replacedName []byte
+		if len(changed) > 0 {
+			replacedName, _ = json.Marshal(name.ValueString())
+		}
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyReplacedName, replacedName)...)
+	}
+	if resp.Diagnostics.HasError() || plannedCost.IsNull() || plannedCost.IsUnknown() {
+		return
+	}
+	planned := budget.planned.add(plannedNodePool{
+		kind:       kind,
+		name:       name.ValueString(),
+		hourlyCost: plannedCost.ValueFloat64(),
+	})
+	if !priorCost.IsNull() && !priorCost.IsUnknown() && plannedCost.ValueFloat64() <= priorCost.ValueFloat64() {
+		return
+	}
+	checkSpendBudget(ctx, ngpcClient, namespace, budget, planned, &resp.Diagnostics)
+}
+
+// checkBidPriceBudget fails the plan if the bid price exceeds the maximum bid price per server of the budget
+func checkBidPriceBudget(budget spendBudget, bidPrice float64, diags *diag.Diagnostics) {
+	if budget.maxBidPricePerServer == 0 || bidPrice <= budget.maxBidPricePerServer {
+		return
+	}
+	diags.AddAttributeError(path.Root(attribBidPrice), "Bid price exceeds the budget",
+		fmt.Sprintf("The bid price of %.3f USD per server and hour exceeds the max_bid_price_per_server of %.3f USD "+
+			"set in the budget of the provider.", bidPrice, budget.maxBidPricePerServer))
+}
+
+// checkSpendBudget fails the plan if the hourly spend of all the node pools of the organization, with the planned
+// node pools in place of their current version, exceeds the maximum hourly spend of the budget. The error details
+// the spend of every node pool.
+func checkSpendBudget(ctx context.Context, ngpcClient ngpc.Client, namespace string, budget spendBudget,
+	planned map[string]plannedNodePool, diags *diag.Diagnostics) {
+	if budget.maxHourlySpend == 0 {
+		return
+	}
+	nodePools, err := listNodePools(ctx, ngpcClient, namespace)
+	if err != nil {
+		diags.AddError("Failed to check the budget", fmt.Sprintf("failed to list the node pools: %s", err.Error()))
+		return
+	}
+	var total float64
+	var breakdown []string
+	for key, nodePool := range planned {
+		total += nodePool.hourlyCost
+		breakdown = append(breakdown, fmt.Sprintf("- %s: %.4f USD/h (planned)", key, nodePool.hourlyCost))
+	}
+	for _, nodePool := range nodePools {
+		key := nodePoolName(nodePool)
+		if _, ok := planned[key]; ok {
+			continue
+		}
+		cost, err := nodePoolHourlyCost(ctx, ngpcClient, nodePool)
+		if err != nil {
+			diags.AddError("Failed to check the budget", err.Error())
+			return
+		}
+		total += cost
+		breakdown = append(breakdown, fmt.Sprintf("- %s: %.4f USD/h", key, cost))
+	}
+	tflog.Debug(ctx, "Checked the spend budget", map[string]any{"total": total, "max": budget.maxHourlySpend})
+	if total <= budget.maxHourlySpend {
+		return
+	}
+	sort.Strings(breakdown)
+	diags.AddError("Planned spend exceeds the budget",
+		fmt.Sprintf("The estimated spend of %.4f USD per hour of the existing node pools of the organization "+
+			"and the node pools planned so far exceeds the max_hourly_spend of %.4f USD set in the budget of the provider.\n\n%s",
+			total, budget.maxHourlySpend, strings.Join(breakdown, "\n")))
+}
+
+// listNodePools returns the spot and on-demand node pools of the namespace, which are not being deleted
+func listNodePools(ctx context.Context, ngpcClient ngpc.Client, namespace string) ([]client.Object, error) {
+	var spotNodePools ngpcv1.SpotNodePoolList
+	if err := ngpcClient.List(ctx, &spotNodePools, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var onDemandNodePools ngpcv1.OnDemandNodePoolList
+	if err := ngpcClient.List(ctx, &onDemandNodePools, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var nodePools []client.Object
+	for i := range spotNodePools.Items {
+		if spotNodePools.Items[i].DeletionTimestamp == nil {
+			nodePools = append(nodePools, &spotNodePools.Items[i])
+		}
+	}
+	for i := range onDemandNodePools.Items {
+		if onDemandNodePools.Items[i].DeletionTimestamp == nil {
+			nodePools = append(nodePools, &onDemandNodePools.Items[i])
+		}
+	}
+	return nodePools, nil
+}
@@ -0,0 +1,227 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+	"github.com/rackerlabs/terraform-provider-spot/internal/provider/resource_spotnodepool"
+)
+
+// nodePoolsClient lists the given spot node pools and no on-demand node pools
+type nodePoolsClient struct {
+	ngpc.Client
+	spotNodePools []ngpcv1.SpotNodePool
+}
+
+func (c *nodePoolsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if spotNodePools, ok := list.(*ngpcv1.SpotNodePoolList); ok {
+		spotNodePools.Items = c.spotNodePools
+	}
+	return nil
+}
+
+// testSpotNodePool returns a spot node pool costing the bid price for each of its desired servers
+func testSpotNodePool(name string, bidPrice string, desired int) ngpcv1.SpotNodePool {
+	pool := ngpcv1.SpotNodePool{}
+	pool.Name = name
+	pool.Spec.BidPrice = bidPrice
+	pool.Spec.Desired = desired
+	return pool
+}
+
+// testBudget returns a budget of 1 USD per hour, along with a client listing node pools of 0.5 and 0.2 USD per hour
+func testBudget() (spendBudget, *nodePoolsClient) {
+	budget := spendBudget{maxHourlySpend: 1, planned: &plannedSpend{nodePools: map[string]plannedNodePool{}}}
+	return budget, &nodePoolsClient{spotNodePools: []ngpcv1.SpotNodePool{
+		testSpotNodePool("pool-a", "0.100", 5),
+		testSpotNodePool("pool-b", "0.050", 4),
+	}}
+}
+
+func TestCheckSpendBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		planned []plannedNodePool
+		wantErr bool
+	}{
+		{"new node pool within the budget", []plannedNodePool{{kind: "spotnodepool", hourlyCost: 0.3}}, false},
+		{"new node pool exceeding the budget", []plannedNodePool{{kind: "spotnodepool", hourlyCost: 0.4}}, true},
+		{"updated node pool counted once", []plannedNodePool{{kind: "spotnodepool", name: "pool-a", hourlyCost: 0.8}}, false},
+		{"updated node pool exceeding the budget", []plannedNodePool{{kind: "spotnodepool", name: "pool-a", hourlyCost: 0.9}}, true},
+		{"on-demand node pool of the same name", []plannedNodePool{{kind: "ondemandnodepool", name: "pool-a", hourlyCost: 0.4}}, true},
+		{
+			name: "new node pools exceeding the budget together",
+			planned: []plannedNodePool{
+				{kind: "spotnodepool", hourlyCost: 0.2},
+				{kind: "ondemandnodepool", hourlyCost: 0.2},
+			},
+			wantErr: true,
+		},
+		{
+			name: "new node pool in place of a destroyed one",
+			planned: []plannedNodePool{
+				{kind: "spotnodepool", name: "pool-a"},
+				{kind: "spotnodepool", hourlyCost: 0.8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget, fake := testBudget()
+			var planned map[string]plannedNodePool
+			for _, nodePool := range tt.planned {
+				planned = budget.planned.add(nodePool)
+			}
+			var diags diag.Diagnostics
+			checkSpendBudget(context.Background(), fake, "org", budget, planned, &diags)
+			if diags.HasError() != tt.wantErr {
+				t.Errorf("checkSpendBudget() errors = %v, wantErr %v", diags.Errors(), tt.wantErr)
+			}
+		})
+	}
+}
+
+// testNodePoolPlan holds the attributes of a spot node pool the budget check reads, a nil name is unknown
+type testNodePoolPlan struct {
+	name        *string
+	serverClass string
+	hourlyCost  float64
+}
+
+// testPlanData returns the raw value of a spot node pool with the attributes, or a null one
+func testPlanData(t *testing.T, nodePool *testNodePoolPlan) tfsdk.Plan {
+	t.Helper()
+	ctx := context.Background()
+	s := resource_spotnodepool.SpotnodepoolResourceSchema(ctx)
+	plan := tfsdk.Plan{Schema: s, Raw: tftypes.NewValue(s.Type().TerraformType(ctx), nil)}
+	if nodePool == nil {
+		return plan
+	}
+	name := types.StringUnknown()
+	if nodePool.name != nil {
+		name = types.StringValue(*nodePool.name)
+	}
+	var diags diag.Diagnostics
+	diags.Append(plan.SetAttribute(ctx, path.Root(attribName), name)...)
+	diags.Append(plan.SetAttribute(ctx, path.Root(attribServerClass), types.StringValue(nodePool.serverClass))...)
+	diags.Append(plan.SetAttribute(ctx, path.Root(attribCloudspaceName), types.StringValue("cloudspace"))...)
+	diags.Append(plan.SetAttribute(ctx, path.Root(attribEstimatedHourlyCost), types.Float64Value(nodePool.hourlyCost))...)
+	if diags.HasError() {
+		t.Fatalf("failed to build the plan: %v", diags.Errors())
+	}
+	return plan
+}
+
+// setEmptyPrivate sets the Private field of the request or response to empty private state data,
+// whose type is internal to the framework.
+func setEmptyPrivate(reqOrResp any) {
+	private := reflect.ValueOf(reqOrResp).Elem().FieldByName("Private")
+	private.Set(reflect.New(private.Type().Elem()))
+}
+
+// planBudget plans the budget of a spot node pool from the prior state to the plan, with the private state
+// of the request, and returns the response.
+func planBudget(t *testing.T, budget spendBudget, fake ngpc.Client, prior *testNodePoolPlan, planned *testNodePoolPlan,
+	private *resource.ModifyPlanResponse) *resource.ModifyPlanResponse {
+	t.Helper()
+	plan := testPlanData(t, planned)
+	state := testPlanData(t, prior)
+	req := resource.ModifyPlanRequest{Plan: plan, State: tfsdk.State(state)}
+	setEmptyPrivate(&req)
+	if private != nil {
+		req.Private = private.Private
+	}
+	resp := &resource.ModifyPlanResponse{Plan: plan}
+	setEmptyPrivate(resp)
+	planNodePoolBudget(context.Background(), req, resp, fake, "org", budget, "spotnodepool")
+	return resp
+}
+
+func TestPlanNodePoolBudget(t *testing.T) {
+	poolA, poolB, poolC := "pool-a", "pool-b", "pool-c"
+
+	t.Run("create", func(t *testing.T) {
+		budget, fake := testBudget()
+		resp := planBudget(t, budget, fake, nil, &testNodePoolPlan{serverClass: "small", hourlyCost: 0.3}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected errors: %v", resp.Diagnostics.Errors())
+		}
+		resp = planBudget(t, budget, fake, nil, &testNodePoolPlan{name: &poolC, serverClass: "small", hourlyCost: 0.1}, nil)
+		if !resp.Diagnostics.HasError() {
+			t.Fatal("expected the second node pool created in the same run to exceed the budget")
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		budget, fake := testBudget()
+		prior := &testNodePoolPlan{name: &poolA, serverClass: "small", hourlyCost: 0.5}
+		resp := planBudget(t, budget, fake, prior, &testNodePoolPlan{name: &poolA, serverClass: "small", hourlyCost: 0.8}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected errors: %v", resp.Diagnostics.Errors())
+		}
+		if replaced, _ := resp.Private.GetKey(context.Background(), keyReplacedName); replaced != nil {
+			t.Errorf("the name of a node pool which is not replaced was kept: %s", replaced)
+		}
+		resp = planBudget(t, budget, fake, prior, &testNodePoolPlan{name: &poolA, serverClass: "small", hourlyCost: 0.9}, nil)
+		if !resp.Diagnostics.HasError() {
+			t.Fatal("expected the update to exceed the budget")
+		}
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		budget, fake := testBudget()
+		prior := &testNodePoolPlan{name: &poolB, serverClass: "small", hourlyCost: 0.2}
+		// Terraform plans the replacement from the prior state first, then again as a create
+		resp := planBudget(t, budget, fake, prior, &testNodePoolPlan{name: &poolB, serverClass: "large", hourlyCost: 0.5}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected errors: %v", resp.Diagnostics.Errors())
+		}
+		if replaced, _ := resp.Private.GetKey(context.Background(), keyReplacedName); string(replaced) != `"pool-b"` {
+			t.Fatalf("got replaced name %s, want \"pool-b\"", replaced)
+		}
+		resp = planBudget(t, budget, fake, nil, &testNodePoolPlan{serverClass: "large", hourlyCost: 0.5}, resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("the replaced node pool was counted: %v", resp.Diagnostics.Errors())
+		}
+		if len(budget.planned.nodePools) != 1 {
+			t.Errorf("got %d planned node pools, want 1", len(budget.planned.nodePools))
+		}
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		budget, fake := testBudget()
+		resp := planBudget(t, budget, fake, &testNodePoolPlan{name: &poolA, serverClass: "small", hourlyCost: 0.5}, nil, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected errors: %v", resp.Diagnostics.Errors())
+		}
+		resp = planBudget(t, budget, fake, nil, &testNodePoolPlan{serverClass: "large", hourlyCost: 0.8}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("the destroyed node pool was counted: %v", resp.Diagnostics.Errors())
+		}
+	})
+
+	t.Run("created during apply", func(t *testing.T) {
+		budget, fake := testBudget()
+		resp := planBudget(t, budget, fake, nil, &testNodePoolPlan{serverClass: "small", hourlyCost: 0.2}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected errors: %v", resp.Diagnostics.Errors())
+		}
+		fake.spotNodePools = append(fake.spotNodePools, testSpotNodePool(poolC, "0.100", 2))
+		budget.planned.markCreated("spotnodepool", poolC, 0.2)
+		resp = planBudget(t, budget, fake, nil, &testNodePoolPlan{serverClass: "small", hourlyCost: 0.1}, nil)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("the created node pool was counted twice: %v", resp.Diagnostics.Errors())
+		}
+	})
+}
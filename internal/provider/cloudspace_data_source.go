@@ -3,7 +3,6 @@ package provider
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
 	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
@@ -13,6 +12,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/oauth2"
 	ktypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/rackerlabs/terraform-provider-spot/internal/provider/datasource_cloudspace"
@@ -28,7 +28,9 @@ func NewCloudspaceDataSource() datasource.DataSource {
 }
 
 type cloudspaceDataSource struct {
-	ngpcClient ngpc.Client
+	ngpcClient  ngpc.Client
+	namespace   string
+	tokenSource oauth2.TokenSource
 }
 
 func (d *cloudspaceDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -53,10 +55,21 @@ func (d *cloudspaceDataSource) Configure(ctx context.Context, req datasource.Con
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
+	d.namespace = spotProviderData.namespace
+	d.tokenSource = spotProviderData.tokenSource
 }
 
 func (d *cloudspaceDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_cloudspace.CloudspaceModel
 
 	// Read Terraform configuration data into the model
@@ -73,11 +86,7 @@ func (d *cloudspaceDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := d.namespace
 	tflog.Debug(ctx, "Reading cloudspace", map[string]any{"name": name, "namespace": namespace})
 	cloudspace := &ngpcv1.CloudSpace{}
 	err = d.ngpcClient.Get(ctx, ktypes.NamespacedName{
@@ -181,11 +190,17 @@ func (d *cloudspaceDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		return
 	}
 
-	token := os.Getenv("RXTSPOT_TOKEN")
-	if token == "" {
-		resp.Diagnostics.AddError("Missing authentication token", "Set RXTSPOT_TOKEN environment variable")
+	if d.tokenSource == nil {
+		resp.Diagnostics.AddError("Missing authentication token", "Provider is not configured with an authentication token")
+		return
+	}
+	// Get the current token from the token source, it is refreshed if it is about to expire
+	accessToken, err := d.tokenSource.Token()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get access token", err.Error())
 		return
 	}
+	token := accessToken.AccessToken
 	kubeconfigVars := KubeconfigVars{
 		OrgName:               "rxtspot",
 		User:                  "ngpc-user",
@@ -2,7 +2,10 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -15,9 +18,15 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/oauth2"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	kversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
 )
@@ -29,12 +38,23 @@ var (
 	_ resource.ResourceWithModifyPlan  = (*cloudspaceResource)(nil)
 )
 
+const (
+	// onDeleteNodepoolsBlock fails the deletion of a cloudspace which still has node pools
+	onDeleteNodepoolsBlock = "block"
+	// onDeleteNodepoolsCascade deletes the node pools of a cloudspace before the cloudspace
+	onDeleteNodepoolsCascade = "cascade"
+)
+
 func NewCloudspaceResource() resource.Resource {
 	return &cloudspaceResource{}
 }
 
 type cloudspaceResource struct {
 	ngpcClient ngpc.Client
+	namespace  string
+	// tokenSource and transportSettings are used to read the version of the control plane of upgraded cloudspaces
+	tokenSource       oauth2.TokenSource
+	transportSettings *ngpcTransportSettings
 }
 
 func (r *cloudspaceResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -59,13 +79,31 @@ func (r *cloudspaceResource) Configure(ctx context.Context, req resource.Configu
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	r.ngpcClient = spotProviderData.ngpcClient
+	r.namespace = spotProviderData.namespace
+	r.tokenSource = spotProviderData.tokenSource
+	r.transportSettings = spotProviderData.transportSettings
 }
 
 func (r *cloudspaceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	planDeletionProtection(ctx, req, resp, "cloudspace", cloudspaceReplaceAttributes)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if req.Plan.Raw.IsNull() {
+		r.planNodePoolsOnDelete(ctx, req, resp)
+		return
+	}
+
 	var regionVal types.String
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribRegion), &regionVal)...)
-	if !regionVal.IsNull() && !regionVal.IsUnknown() {
+	// Validation is skipped if the provider configuration is not known yet
+	if !regionVal.IsNull() && !regionVal.IsUnknown() && r.ngpcClient != nil {
 		regionsList, err := listRegions(ctx, r.ngpcClient)
 		if err != nil {
 			resp.Diagnostics.AddWarning("Failed to validate region", err.Error())
@@ -85,9 +123,64 @@ func (r *cloudspaceResource) ModifyPlan(ctx context.Context, req resource.Modify
 			}
 		}
 	}
+
+	// Kubernetes version can be upgraded in place one minor version at a time
+	if !req.State.Raw.IsNull() && !req.Plan.Raw.IsNull() {
+		var stateVersion, planVersion types.String
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribKubernetesVersion), &stateVersion)...)
+		resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribKubernetesVersion), &planVersion)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if !stateVersion.IsNull() && !stateVersion.IsUnknown() && !planVersion.IsNull() && !planVersion.IsUnknown() &&
+			stateVersion.ValueString() != "" && stateVersion.ValueString() != planVersion.ValueString() {
+			if err := validateKubernetesUpgrade(stateVersion.ValueString(), planVersion.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root(attribKubernetesVersion), "Invalid kubernetes version upgrade", err.Error())
+				return
+			}
+		}
+	}
+}
+
+// planNodePoolsOnDelete warns if a cloudspace planned to be destroyed with on_delete_nodepools set to block
+// still has node pools. It is not an error since the node pools may be destroyed before the cloudspace by the
+// same plan, the deletion fails on apply if they are not.
+func (r *cloudspaceResource) planNodePoolsOnDelete(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if r.ngpcClient == nil {
+		return
+	}
+	var state resource_cloudspace.CloudspaceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() || state.OnDeleteNodepools.ValueString() != onDeleteNodepoolsBlock {
+		return
+	}
+	name := state.CloudspaceName.ValueString()
+	if name == "" {
+		name = state.Name.ValueString()
+	}
+	name, err := getNameFromNameOrId(name, state.Id.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get name", err.Error())
+		return
+	}
+	nodePools, err := attachedNodePools(ctx, r.ngpcClient, name, r.namespace)
+	if err != nil {
+		resp.Diagnostics.AddWarning("Failed to list the node pools of the cloudspace", err.Error())
+		return
+	}
+	if len(nodePools) > 0 {
+		resp.Diagnostics.AddWarning("Cloudspace still has node pools",
+			fmt.Sprintf("The cloudspace %s still has the node pools %s. Its deletion fails unless they are deleted before it, for example by this plan. "+
+				"Set on_delete_nodepools to %q and apply to delete them with the cloudspace.",
+				name, strings.Join(nodePoolNames(nodePools), ", "), onDeleteNodepoolsCascade))
+	}
 }
 
 func (r *cloudspaceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_cloudspace.CloudspaceModel
 
 	// Read Terraform plan data into the model
@@ -106,11 +199,7 @@ func (r *cloudspaceResource) Create(ctx context.Context, req resource.CreateRequ
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 	tflog.Debug(ctx, "Creating cloudspace", map[string]any{"name": name, "namespace": namespace})
 
 	cloudspace := &ngpcv1.CloudSpace{
@@ -122,18 +211,10 @@ func (r *cloudspaceResource) Create(ctx context.Context, req resource.CreateRequ
 			Name:      name,
 			Namespace: namespace,
 		},
-		Spec: ngpcv1.CloudSpaceSpec{
-			Region:            data.Region.ValueString(),
-			Cloud:             "default",
-			HAControlPlane:    data.HacontrolPlane.ValueBool(),
-			Webhook:           data.PreemptionWebhook.ValueString(),
-			DeploymentType:    data.DeploymentType.ValueString(),
-			KubernetesVersion: data.KubernetesVersion.ValueString(),
-			CNI:               data.Cni.ValueString(),
-		},
+		Spec: cloudspaceSpecFromModel(&data),
 	}
 	tflog.Info(ctx, "Creating cloudspace", map[string]any{"name": cloudspace.ObjectMeta.Name})
-	err = r.ngpcClient.Create(ctx, cloudspace)
+	err = r.ngpcClient.Create(ctx, cloudspace, client.FieldOwner(fieldManager))
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to create cloudspace", err.Error())
 		return
@@ -144,12 +225,14 @@ func (r *cloudspaceResource) Create(ctx context.Context, req resource.CreateRequ
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.refreshEstimatedCosts(ctx, name, namespace, &data)
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(cloudspace.ObjectMeta.ResourceVersion))...)
 	data.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 	tflog.Debug(ctx, "Updated local state")
 
-	if data.WaitUntilReady.ValueBool() {
+	// Nodes can only be waited for once the control plane is ready
+	if data.WaitUntilReady.ValueBool() || data.WaitForNodes.ValueBool() {
 		tflog.Info(ctx, "Waiting for cloudspace to be ready")
 		// If you dont find the Timeouts attribute in the data, run make generate-code
 		createTimeout, diags := data.Timeouts.Create(ctx, DefaultCloudSpaceCreateTimeout)
@@ -157,17 +240,31 @@ func (r *cloudspaceResource) Create(ctx context.Context, req resource.CreateRequ
 			resp.Diagnostics.Append(diags...)
 			return
 		}
-		maxRetries := uint64(createTimeout/DefaultRefreshInterval) + 1
-		backoffStrategy := backoff.WithMaxRetries(backoff.NewConstantBackOff(DefaultRefreshInterval), maxRetries)
-		err := backoff.Retry(waitForCloudSpaceControlPlaneReady(ctx, r.ngpcClient, name, namespace), backoffStrategy)
+		createStart := time.Now()
+		err := waitFor(ctx, r.ngpcClient, createTimeout,
+			waitForCloudSpaceControlPlaneReady(r.ngpcClient, name, namespace),
+			cloudSpaceScope(name, namespace))
 		if err != nil {
 			resp.Diagnostics.AddWarning("Failed to wait for cloudspace to be ready", err.Error())
 			return
 		}
+		// api_server_endpoint and the status are known only once the control plane is ready
+		resp.Diagnostics.Append(r.refreshStatus(ctx, name, namespace, &data)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		if data.WaitForNodes.ValueBool() {
+			resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, createTimeout-time.Since(createStart))...)
+		}
 	}
 }
 
 func (r *cloudspaceResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if !checkProviderConfiguredForRead(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_cloudspace.CloudspaceModel
 
 	// Read Terraform prior state data into the model
@@ -184,11 +281,7 @@ func (r *cloudspaceResource) Read(ctx context.Context, req resource.ReadRequest,
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	// Read API call logic
 	tflog.Debug(ctx, "Reading cloudspace", map[string]any{"name": name, "namespace": namespace})
@@ -197,23 +290,48 @@ func (r *cloudspaceResource) Read(ctx context.Context, req resource.ReadRequest,
 		Name:      name,
 		Namespace: namespace,
 	}, cloudspace)
+	if apierrors.IsNotFound(err) {
+		// Deleted outside of terraform, removing it from the state makes terraform plan a recreate
+		resp.Diagnostics.AddWarning("Cloudspace not found",
+			fmt.Sprintf("Cloudspace %s no longer exists and is removed from the state", name))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get cloudspace", err.Error())
 		return
 	}
+	if cloudspace.Status.Phase == ngpcv1.CloudSpacePhaseDeleting {
+		resp.Diagnostics.AddWarning("Cloudspace is being deleted",
+			fmt.Sprintf("Cloudspace %s is being deleted and is removed from the state", name))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	diags := setCloudspaceState(ctx, cloudspace, &data)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.refreshEstimatedCosts(ctx, name, namespace, &data)
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(cloudspace.ObjectMeta.ResourceVersion))...)
 	data.LastUpdated = types.StringNull()
+	if data.DeletionProtection.IsNull() {
+		// Not known after an import
+		data.DeletionProtection = types.BoolValue(false)
+	}
+	if data.WaitForNodes.IsNull() {
+		data.WaitForNodes = types.BoolValue(false)
+	}
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *cloudspaceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var plan, state resource_cloudspace.CloudspaceModel
 
 	// Read Terraform plan data into the model
@@ -236,11 +354,7 @@ func (r *cloudspaceResource) Update(ctx context.Context, req resource.UpdateRequ
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	if plan.DeploymentType.ValueString() != state.DeploymentType.ValueString() {
 		resp.Diagnostics.AddError("Update to the deployment_type is not allowed", fmt.Sprintf("%s to %s is not allowed", state.DeploymentType.ValueString(), plan.DeploymentType.ValueString()))
@@ -253,8 +367,10 @@ func (r *cloudspaceResource) Update(ctx context.Context, req resource.UpdateRequ
 		return
 	}
 	resourceVersion := string(resourceVersionBytes)
-	// TODO: Find the difference between state and plan and update only the changed fields using patch
-	cloudspace := &ngpcv1.CloudSpace{
+	// The patch is the difference between the spec in the state and the planned spec,
+	// fields managed by the backend are not part of it. If the cloudspace was changed
+	// since it was read, the planned spec is applied to its latest version.
+	base := &ngpcv1.CloudSpace{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "CloudSpace",
 			APIVersion: "ngpc.rxt.io/v1",
@@ -264,16 +380,20 @@ func (r *cloudspaceResource) Update(ctx context.Context, req resource.UpdateRequ
 			Namespace:       namespace,
 			ResourceVersion: resourceVersion,
 		},
-		Spec: ngpcv1.CloudSpaceSpec{
-			Region:         plan.Region.ValueString(),
-			Cloud:          "default",
-			HAControlPlane: plan.HacontrolPlane.ValueBool(),
-			Webhook:        plan.PreemptionWebhook.ValueString(),
-			DeploymentType: plan.DeploymentType.ValueString(),
-		},
+		Spec: cloudspaceSpecFromModel(&state),
+	}
+	upgrading := plan.KubernetesVersion.ValueString() != state.KubernetesVersion.ValueString()
+	if upgrading {
+		tflog.Info(ctx, "Upgrading cloudspace kubernetes version", map[string]any{
+			"name": name,
+			"from": state.KubernetesVersion.ValueString(),
+			"to":   plan.KubernetesVersion.ValueString(),
+		})
 	}
 	tflog.Debug(ctx, "Updating cloudspace", map[string]any{"name": name, "namespace": namespace})
-	err = r.ngpcClient.Update(ctx, cloudspace)
+	cloudspace, err := updateObject(ctx, r.ngpcClient, base, func(cs *ngpcv1.CloudSpace) {
+		applyCloudspaceModel(&cs.Spec, &plan)
+	})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to update cloudspace", err.Error())
 		return
@@ -286,11 +406,122 @@ func (r *cloudspaceResource) Update(ctx context.Context, req resource.UpdateRequ
 	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(cloudspace.ObjectMeta.ResourceVersion))...)
 	state.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	state.DeletionProtection = plan.DeletionProtection
+	state.OnDeleteNodepools = plan.OnDeleteNodepools
+	state.WaitForNodes = plan.WaitForNodes
 	state.WaitUntilReady = plan.WaitUntilReady
+	state.Timeouts = plan.Timeouts
+	if plan.EstimatedHourlyCost.IsUnknown() || plan.EstimatedMonthlyCost.IsUnknown() {
+		r.refreshEstimatedCosts(ctx, name, namespace, &state)
+	}
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+
+	// The waits for the upgrade and for the nodes share the update timeout
+	updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultCloudSpaceUpdateTimeout)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	updateStart := time.Now()
+	if upgrading && plan.WaitUntilReady.ValueBool() {
+		tflog.Info(ctx, "Waiting for cloudspace upgrade to complete")
+		err := waitFor(ctx, r.ngpcClient, updateTimeout,
+			waitForCloudSpaceUpgraded(r.ngpcClient, r.tokenSource, r.transportSettings, name, namespace, plan.KubernetesVersion.ValueString()),
+			cloudSpaceScope(name, namespace))
+		if err != nil {
+			resp.Diagnostics.AddWarning("Failed to wait for cloudspace upgrade to complete", err.Error())
+			return
+		}
+		resp.Diagnostics.Append(r.refreshStatus(ctx, name, namespace, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	}
+
+	if plan.WaitForNodes.ValueBool() {
+		resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, updateTimeout-time.Since(updateStart))...)
+	}
+}
+
+// waitForNodes waits until the node pools of the cloudspace have their nodes. A timeout is reported
+// as a warning listing the pending allocations, the cloudspace itself is created or updated.
+func (r *cloudspaceResource) waitForNodes(ctx context.Context, name string, namespace string, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+	tflog.Info(ctx, "Waiting for the nodes of the cloudspace", map[string]any{"name": name})
+	err := waitFor(ctx, r.ngpcClient, timeout,
+		waitForCloudSpaceNodes(r.ngpcClient, name, namespace),
+		cloudSpaceScope(name, namespace), spotNodePoolScope("", namespace))
+	if err != nil {
+		diags.AddWarning("Failed to wait for the nodes of the cloudspace",
+			err.Error()+describePendingAllocations(ctx, r.ngpcClient, name, namespace))
+	}
+	return diags
+}
+
+// refreshEstimatedCosts sums the estimated costs of the node pools attached to the cloudspace.
+// Known costs are kept if the node pools can not be read.
+func (r *cloudspaceResource) refreshEstimatedCosts(ctx context.Context, name string, namespace string, state *resource_cloudspace.CloudspaceModel) {
+	nodePools, err := attachedNodePools(ctx, r.ngpcClient, name, namespace)
+	var hourlyCost float64
+	if err == nil {
+		hourlyCost, err = nodePoolsHourlyCost(ctx, r.ngpcClient, nodePools)
+	}
+	if err != nil {
+		tflog.Warn(ctx, "Failed to estimate the costs of the cloudspace", map[string]any{"name": name, "error": err.Error()})
+		if state.EstimatedHourlyCost.IsUnknown() || state.EstimatedMonthlyCost.IsUnknown() {
+			state.EstimatedHourlyCost, state.EstimatedMonthlyCost = types.Float64Null(), types.Float64Null()
+		}
+		return
+	}
+	state.EstimatedHourlyCost, state.EstimatedMonthlyCost = estimatedCosts(hourlyCost)
+}
+
+// cloudspaceSpecFromModel returns the part of the cloudspace spec managed by terraform
+func cloudspaceSpecFromModel(model *resource_cloudspace.CloudspaceModel) ngpcv1.CloudSpaceSpec {
+	var spec ngpcv1.CloudSpaceSpec
+	applyCloudspaceModel(&spec, model)
+	return spec
+}
+
+// applyCloudspaceModel sets the fields of the cloudspace spec managed by terraform,
+// other fields are left untouched.
+func applyCloudspaceModel(spec *ngpcv1.CloudSpaceSpec, model *resource_cloudspace.CloudspaceModel) {
+	spec.Region = model.Region.ValueString()
+	spec.Cloud = "default"
+	spec.HAControlPlane = model.HacontrolPlane.ValueBool()
+	spec.Webhook = model.PreemptionWebhook.ValueString()
+	spec.DeploymentType = model.DeploymentType.ValueString()
+	spec.KubernetesVersion = model.KubernetesVersion.ValueString()
+	spec.CNI = model.Cni.ValueString()
+}
+
+// refreshStatus sets the status attributes of the state from the latest cloudspace,
+// spec attributes are left as planned.
+func (r *cloudspaceResource) refreshStatus(ctx context.Context, name string, namespace string, state *resource_cloudspace.CloudspaceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	cloudspace := &ngpcv1.CloudSpace{}
+	err := r.ngpcClient.Get(ctx, ktypes.NamespacedName{
+		Name:      name,
+		Namespace: namespace,
+	}, cloudspace)
+	if err != nil {
+		diags.AddWarning("Failed to refresh cloudspace status", err.Error())
+		return diags
+	}
+	state.FirstReadyTimestamp = types.StringValue(cloudspace.Status.FirstReadyTimestamp.UTC().Format(time.RFC3339))
+	state.ApiServerEndpoint = types.StringValue(cloudspace.Status.APIServerEndpoint)
+	state.Phase = types.StringValue(string(cloudspace.Status.Phase))
+	state.Health = types.StringValue(cloudspace.Status.Health)
+	state.Reason = types.StringValue(cloudspace.Status.Reason)
+	return diags
 }
 
 func (r *cloudspaceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_cloudspace.CloudspaceModel
 
 	// Read Terraform prior state data into the model
@@ -308,11 +539,51 @@ func (r *cloudspaceResource) Delete(ctx context.Context, req resource.DeleteRequ
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
+	namespace := r.namespace
+
+	if !checkDeletionProtection(data.DeletionProtection, "cloudspace", name, &resp.Diagnostics) {
 		return
 	}
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, DefaultCloudSpaceDeleteTimeout)
+	if diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+
+	// The node pools and the cloudspace are deleted within the same timeout
+	deadline := time.Now().Add(deleteTimeout)
+
+	// Node pools still listed in the cloudspace are either deleted first or block the deletion
+	if onDelete := data.OnDeleteNodepools.ValueString(); onDelete != "" {
+		nodePools, err := attachedNodePools(ctx, r.ngpcClient, name, namespace)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to list the node pools of the cloudspace", err.Error())
+			return
+		}
+		if len(nodePools) > 0 && onDelete == onDeleteNodepoolsBlock {
+			resp.Diagnostics.AddError("Cloudspace still has node pools",
+				fmt.Sprintf("The cloudspace %s still has the node pools %s. Delete them first, or set on_delete_nodepools to %q and apply to delete them with the cloudspace.",
+					name, strings.Join(nodePoolNames(nodePools), ", "), onDeleteNodepoolsCascade))
+			return
+		}
+		if len(nodePools) > 0 {
+			tflog.Info(ctx, "Deleting the node pools of the cloudspace", map[string]any{"name": name, "nodepools": nodePoolNames(nodePools)})
+			for _, nodePool := range nodePools {
+				err = r.ngpcClient.Delete(ctx, nodePool)
+				if err != nil && !apierrors.IsNotFound(err) {
+					resp.Diagnostics.AddError("Failed to delete the node pools of the cloudspace", err.Error())
+					return
+				}
+			}
+			err = waitFor(ctx, r.ngpcClient, time.Until(deadline),
+				waitForNodePoolsDeleted(r.ngpcClient, nodePools),
+				spotNodePoolScope("", namespace), onDemandNodePoolScope("", namespace))
+			if err != nil {
+				resp.Diagnostics.AddError("Failed to wait for the node pools of the cloudspace to be deleted", err.Error())
+				return
+			}
+		}
+	}
 
 	// Delete API call logic
 	tflog.Debug(ctx, "Deleting cloudspace", map[string]any{"name": name, "namespace": namespace})
@@ -326,10 +597,20 @@ func (r *cloudspaceResource) Delete(ctx context.Context, req resource.DeleteRequ
 			Namespace: namespace,
 		},
 	})
-	if err != nil {
+	if err != nil && !apierrors.IsNotFound(err) {
 		resp.Diagnostics.AddError("Failed to delete cloudspace", err.Error())
 		return
 	}
+
+	// A cloudspace with the same name can not be created until the old one is gone
+	tflog.Info(ctx, "Waiting for cloudspace to be deleted", map[string]any{"name": name})
+	err = waitFor(ctx, r.ngpcClient, time.Until(deadline),
+		waitForCloudSpaceDeleted(r.ngpcClient, name, namespace),
+		cloudSpaceScope(name, namespace))
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to wait for cloudspace to be deleted", err.Error())
+		return
+	}
 	tflog.Info(ctx, "Deleted cloudspace", map[string]any{"name": name})
 }
 
@@ -355,6 +636,10 @@ func setCloudspaceState(ctx context.Context, cloudspace *ngpcv1.CloudSpace, stat
 		state.PreemptionWebhook = types.StringNull()
 	}
 	state.FirstReadyTimestamp = types.StringValue(cloudspace.Status.FirstReadyTimestamp.UTC().Format(time.RFC3339))
+	state.ApiServerEndpoint = types.StringValue(cloudspace.Status.APIServerEndpoint)
+	state.Phase = types.StringValue(string(cloudspace.Status.Phase))
+	state.Health = types.StringValue(cloudspace.Status.Health)
+	state.Reason = types.StringValue(cloudspace.Status.Reason)
 
 	// Always set SpotnodepoolIds to a known value since it may not be available after initial cloudspace creation
 	// This prevents Terraform from seeing an "unknown" value after apply
@@ -421,14 +706,16 @@ func setCloudspaceState(ctx context.Context, cloudspace *ngpcv1.CloudSpace, stat
 }
 
 // This function returns retry function that waits for cloudspace to be ready with some resilience
-// TODO: Is there non-polling based approach?
-func waitForCloudSpaceControlPlaneReady(ctx context.Context, ngpcClient ngpc.Client, name string, namespace string) backoff.Operation {
+func waitForCloudSpaceControlPlaneReady(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
 	consecutiveErrorCount := 0
 	maxConsecutiveErrors := 3
+	// Checks triggered by watch events are more frequent than polls, the Error phase is counted
+	// at most once per refresh interval so that it has to persist for as long as when polling
+	var lastErrorCountTime time.Time
 	startTime := time.Now()
 	initialGracePeriod := 2 * time.Minute
 
-	return func() error {
+	return func(ctx context.Context) error {
 		tflog.Debug(ctx, "Checking cloudspace readiness status", map[string]any{
 			"name":      name,
 			"namespace": namespace,
@@ -478,7 +765,10 @@ func waitForCloudSpaceControlPlaneReady(ctx context.Context, ngpcClient ngpc.Cli
 					name, cloudspace.Status.Reason)
 			}
 
-			consecutiveErrorCount++
+			if time.Since(lastErrorCountTime) >= DefaultRefreshInterval {
+				consecutiveErrorCount++
+				lastErrorCountTime = time.Now()
+			}
 			if consecutiveErrorCount >= maxConsecutiveErrors {
 				// If too many consecutive errors, give up
 				return backoff.Permanent(fmt.Errorf("cloudspace %s is persistently in Error phase: %s",
@@ -513,3 +803,231 @@ func waitForCloudSpaceControlPlaneReady(ctx context.Context, ngpcClient ngpc.Cli
 		}
 	}
 }
+
+// waitForCloudSpaceDeleted returns retry function that waits until the cloudspace is gone.
+// The error returned while waiting carries the last phase and reason of the cloudspace.
+func waitForCloudSpaceDeleted(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		cloudspace := &ngpcv1.CloudSpace{}
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, cloudspace)
+		if apierrors.IsNotFound(err) {
+			tflog.Debug(ctx, "Cloudspace is deleted", map[string]any{"name": name})
+			return nil
+		}
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to get cloudspace: %w", err))
+		}
+		tflog.Debug(ctx, "Cloudspace deletion status", map[string]any{
+			"name":   name,
+			"phase":  cloudspace.Status.Phase,
+			"reason": cloudspace.Status.Reason,
+			"age":    time.Since(startTime).String(),
+		})
+		return fmt.Errorf("cloudspace %s is not deleted after %s (phase: %s, reason: %s)",
+			name, time.Since(startTime).Round(time.Second), cloudspace.Status.Phase, cloudspace.Status.Reason)
+	}
+}
+
+// attachedNodePools returns the spot and on-demand node pools listed in the spec of the cloudspace,
+// which still exist and are not being deleted already.
+func attachedNodePools(ctx context.Context, ngpcClient ngpc.Client, name string, namespace string) ([]client.Object, error) {
+	cloudspace := &ngpcv1.CloudSpace{}
+	err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, cloudspace)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cloudspace: %w", err)
+	}
+	var candidates []client.Object
+	for _, poolName := range cloudspace.Spec.BidRequests {
+		candidates = append(candidates, &ngpcv1.SpotNodePool{})
+		candidates[len(candidates)-1].SetName(poolName)
+	}
+	for _, poolName := range cloudspace.Spec.OnDemandRequests {
+		candidates = append(candidates, &ngpcv1.OnDemandNodePool{})
+		candidates[len(candidates)-1].SetName(poolName)
+	}
+	var nodePools []client.Object
+	for _, nodePool := range candidates {
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: nodePool.GetName(), Namespace: namespace}, nodePool)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node pool %s: %w", nodePool.GetName(), err)
+		}
+		if nodePool.GetDeletionTimestamp() == nil {
+			nodePools = append(nodePools, nodePool)
+		}
+	}
+	return nodePools, nil
+}
+
+// nodePoolNames returns the names of the node pools prefixed with their kind
+func nodePoolNames(nodePools []client.Object) []string {
+	names := make([]string, len(nodePools))
+	for i, nodePool := range nodePools {
+		names[i] = nodePoolName(nodePool)
+	}
+	return names
+}
+
+// nodePoolName returns the name of the node pool prefixed with its kind
+func nodePoolName(nodePool client.Object) string {
+	switch nodePool.(type) {
+	case *ngpcv1.OnDemandNodePool:
+		return "ondemandnodepool/" + nodePool.GetName()
+	default:
+		return "spotnodepool/" + nodePool.GetName()
+	}
+}
+
+func waitForNodePoolsDeleted(ngpcClient ngpc.Client, nodePools []client.Object) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		var remaining []client.Object
+		for _, nodePool := range nodePools {
+			current := nodePool.DeepCopyObject().(client.Object)
+			err := ngpcClient.Get(ctx, client.ObjectKeyFromObject(nodePool), current)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("failed to get node pool %s: %w", nodePool.GetName(), err))
+			}
+			remaining = append(remaining, nodePool)
+		}
+		if len(remaining) == 0 {
+			tflog.Debug(ctx, "Node pools are deleted")
+			return nil
+		}
+		tflog.Debug(ctx, "Node pools deletion status", map[string]any{
+			"remaining": nodePoolNames(remaining),
+			"age":       time.Since(startTime).String(),
+		})
+		return fmt.Errorf("node pools %s are not deleted after %s",
+			strings.Join(nodePoolNames(remaining), ", "), time.Since(startTime).Round(time.Second))
+	}
+}
+
+// validateKubernetesUpgrade returns an error if the upgrade from the current to the target version
+// is a downgrade, changes the major version or skips a minor version.
+func validateKubernetesUpgrade(current string, target string) error {
+	currentVersion, err := version.ParseGeneric(current)
+	if err != nil {
+		return fmt.Errorf("failed to parse current version %s: %w", current, err)
+	}
+	targetVersion, err := version.ParseGeneric(target)
+	if err != nil {
+		return fmt.Errorf("failed to parse version %s: %w", target, err)
+	}
+	if targetVersion.LessThan(currentVersion) {
+		return fmt.Errorf("downgrade from %s to %s is not supported", current, target)
+	}
+	if targetVersion.Major() != currentVersion.Major() {
+		return fmt.Errorf("upgrade from %s to %s changes the major version, which is not supported", current, target)
+	}
+	if targetVersion.Minor() > currentVersion.Minor()+1 {
+		return fmt.Errorf("upgrade from %s to %s skips minor versions, upgrade to %d.%d first",
+			current, target, currentVersion.Major(), currentVersion.Minor()+1)
+	}
+	return nil
+}
+
+// waitForCloudSpaceUpgraded returns retry function that waits for the cloudspace to go through the Upgrading
+// phase after its kubernetes version is changed, until the API server of the control plane reports the
+// target version. The version is read from the API server as the status of the cloudspace does not report it.
+func waitForCloudSpaceUpgraded(ngpcClient ngpc.Client, tokenSource oauth2.TokenSource, transportSettings *ngpcTransportSettings,
+	name string, namespace string, targetVersion string) waitCondition {
+	startTime := time.Now()
+	return func(ctx context.Context) error {
+		cloudspace := &ngpcv1.CloudSpace{}
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		}, cloudspace)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to get cloudspace: %w", err))
+		}
+
+		tflog.Debug(ctx, "Cloudspace upgrade status", map[string]any{
+			"name":   name,
+			"phase":  cloudspace.Status.Phase,
+			"reason": cloudspace.Status.Reason,
+			"age":    time.Since(startTime).String(),
+		})
+
+		switch cloudspace.Status.Phase {
+		case ngpcv1.CloudSpacePhaseDeleting:
+			return backoff.Permanent(fmt.Errorf("cloudspace %s is being deleted", name))
+		case ngpcv1.CloudSpacePhaseError:
+			return backoff.Permanent(fmt.Errorf("cloudspace %s failed to upgrade: %s", name, cloudspace.Status.Reason))
+		case ngpcv1.CloudSpacePhaseUpgrading, ngpcv1.CloudSpacePhaseProvisioning:
+			return fmt.Errorf("cloudspace %s is in %s phase", name, cloudspace.Status.Phase)
+		}
+		if len(cloudspace.Status.APIServerEndpoint) == 0 {
+			return fmt.Errorf("cloudspace %s is not ready yet (phase: %s)", name, cloudspace.Status.Phase)
+		}
+		// The control plane may still report the previous version until the controller picks up the change
+		reported, err := controlPlaneVersion(ctx, tokenSource, transportSettings, cloudspace.Status.APIServerEndpoint)
+		if err != nil {
+			return fmt.Errorf("cloudspace %s: %w", name, err)
+		}
+		if !isVersionReported(reported, targetVersion) {
+			return fmt.Errorf("control plane of cloudspace %s reports version %s, waiting for %s", name, reported, targetVersion)
+		}
+		tflog.Debug(ctx, "Cloudspace upgrade is complete", map[string]any{"name": name, "version": reported})
+		return nil
+	}
+}
+
+// controlPlaneVersion returns the version reported by the API server of a cloudspace, which is reached
+// with the access token like with the kubeconfig of the cloudspace, and the TLS and proxy settings of the provider.
+func controlPlaneVersion(ctx context.Context, tokenSource oauth2.TokenSource, transportSettings *ngpcTransportSettings,
+	apiServerEndpoint string) (string, error) {
+	if tokenSource == nil || transportSettings == nil {
+		return "", errors.New("provider is not configured with an authentication token")
+	}
+	token, err := tokenSource.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	cfg := transportSettings.restConfig(fmt.Sprintf("https://%s/", apiServerEndpoint))
+	cfg.BearerToken = token.AccessToken
+	cfg.Timeout = controlPlaneVersionTimeout
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to create the client of the control plane: %w", err)
+	}
+	body, err := discoveryClient.RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the version of the control plane: %w", err)
+	}
+	var info kversion.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", fmt.Errorf("failed to parse the version of the control plane: %w", err)
+	}
+	return info.GitVersion, nil
+}
+
+// isVersionReported returns true if the version reported by a control plane, like v1.30.10+k3s1,
+// has the major, minor and patch versions of the target version.
+func isVersionReported(reported string, target string) bool {
+	reportedVersion, err := version.ParseGeneric(reported)
+	if err != nil {
+		return false
+	}
+	targetVersion, err := version.ParseGeneric(target)
+	if err != nil {
+		return false
+	}
+	return reportedVersion.Major() == targetVersion.Major() && reportedVersion.Minor() == targetVersion.Minor() &&
+		reportedVersion.Patch() == targetVersion.Patch()
+}
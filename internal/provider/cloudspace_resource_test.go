@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestValidateKubernetesUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		wantErr bool
+	}{
+		{"same version", "1.30.10", "1.30.10", false},
+		{"patch upgrade", "1.30.1", "1.30.10", false},
+		{"minor upgrade", "1.29.6", "1.30.10", false},
+		{"minor upgrade with v prefix", "v1.30.10", "v1.31.1", false},
+		{"minor upgrade to lower patch", "1.30.10", "1.31.1", false},
+		{"skips a minor version", "1.29.6", "1.31.1", true},
+		{"minor downgrade", "1.31.1", "1.30.10", true},
+		{"patch downgrade", "1.30.10", "1.30.1", true},
+		{"major upgrade", "1.31.1", "2.0.0", true},
+		{"invalid current version", "latest", "1.30.10", true},
+		{"invalid target version", "1.30.10", "next", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKubernetesUpgrade(tt.current, tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateKubernetesUpgrade(%q, %q) error = %v, wantErr %v", tt.current, tt.target, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsVersionReported(t *testing.T) {
+	tests := []struct {
+		reported string
+		target   string
+		want     bool
+	}{
+		{"v1.30.10", "1.30.10", true},
+		{"v1.30.10+k3s1", "1.30.10", true},
+		{"v1.30.10-eks-1234", "1.30.10", true},
+		{"1.30.10", "v1.30.10", true},
+		{"v1.29.6", "1.30.10", false},
+		{"v1.30.1", "1.30.10", false},
+		{"v1.30.100", "1.30.10", false},
+		{"", "1.30.10", false},
+		{"v1.30.10", "", false},
+	}
+	for _, tt := range tests {
+		if got := isVersionReported(tt.reported, tt.target); got != tt.want {
+			t.Errorf("isVersionReported(%q, %q) = %v, want %v", tt.reported, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestControlPlaneVersion(t *testing.T) {
+	var authorization string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"gitVersion": "v1.30.10+k3s1"}`))
+	}))
+	defer server.Close()
+	caBundle := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "access-token"})
+	endpoint := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name     string
+		settings *ngpcTransportSettings
+		wantErr  bool
+	}{
+		{"certificate not trusted", &ngpcTransportSettings{}, true},
+		{"certificate trusted by the ca bundle", &ngpcTransportSettings{caBundle: caBundle}, false},
+		{"certificate not verified", &ngpcTransportSettings{insecure: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorization = ""
+			got, err := controlPlaneVersion(context.Background(), tokenSource, tt.settings, endpoint)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got version %s", got)
+				}
+				if authorization != "" {
+					t.Errorf("the token was sent to an untrusted server")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != "v1.30.10+k3s1" {
+				t.Errorf("got version %s, want v1.30.10+k3s1", got)
+			}
+			if authorization != "Bearer access-token" {
+				t.Errorf("got authorization %q, want the access token", authorization)
+			}
+		})
+	}
+}
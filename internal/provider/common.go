@@ -2,6 +2,13 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
 	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
@@ -9,14 +16,117 @@ import (
 
 const (
 	keyResourceVersion = "resource_version"
+	// keyReplacedName holds the name of the node pool being replaced, in the private state of its plan
+	keyReplacedName = "replaced_name"
 
 	// attribute names defined in the provider_code_spec.json are
 	// defined as constants here, to avoid typos.
 	// Make sure to update these if the provider_code_spec.json changes.
-	attribRegion      = "region"
-	attribServerClass = "server_class"
+	attribRegion               = "region"
+	attribServerClass          = "server_class"
+	attribKubernetesVersion    = "kubernetes_version"
+	attribDeletionProtection   = "deletion_protection"
+	attribBidPrice             = "bid_price"
+	attribBidStrategy          = "bid_strategy"
+	attribDesiredServerCount   = "desired_server_count"
+	attribEstimatedHourlyCost  = "estimated_hourly_cost"
+	attribEstimatedMonthlyCost = "estimated_monthly_cost"
+	attribCloudspaceName       = "cloudspace_name"
+	attribDeploymentType       = "deployment_type"
+	attribName                 = "name"
+	attribNamePrefix           = "name_prefix"
+)
+
+var (
+	// cloudspaceReplaceAttributes are the attributes of the cloudspace whose change requires its replacement
+	cloudspaceReplaceAttributes = []string{attribCloudspaceName, attribDeploymentType, attribName, attribRegion}
+	// nodePoolReplaceAttributes are the attributes of the node pools whose change requires their replacement
+	nodePoolReplaceAttributes = []string{attribCloudspaceName, attribName, attribNamePrefix, attribServerClass}
 )
 
+// checkProviderConfigured adds an error if the clients are not created, which is the case when
+// the provider configuration depends on values not known yet. Data sources and the changes of
+// resources can not proceed without the Spot backend.
+func checkProviderConfigured(client ngpc.Client, diags *diag.Diagnostics) bool {
+	if client != nil {
+		return true
+	}
+	diags.AddError("Provider configuration is not known",
+		"The provider configuration depends on values that are not known until apply, hence the Spot backend can not be queried yet.")
+	return false
+}
+
+// checkProviderConfiguredForRead adds a warning if the clients are not created because the provider
+// configuration is not known yet, for example during plan. The resource keeps its prior state then,
+// it is refreshed once the configuration is known.
+func checkProviderConfiguredForRead(client ngpc.Client, diags *diag.Diagnostics) bool {
+	if client != nil {
+		return true
+	}
+	diags.AddWarning("Provider configuration is not known",
+		"The provider configuration depends on values that are not known until apply, hence the resource is not refreshed "+
+			"and its prior state is used.")
+	return false
+}
+
+// checkDeletionProtection adds an error if the deletion protection of the resource is enabled
+func checkDeletionProtection(protection types.Bool, kind string, name string, diags *diag.Diagnostics) bool {
+	if !protection.ValueBool() {
+		return true
+	}
+	diags.AddError("Deletion protection is enabled",
+		fmt.Sprintf("The %s %s can not be deleted while deletion_protection is true. Set it to false and apply, then destroy it.", kind, name))
+	return false
+}
+
+// planDeletionProtection flags the planned destroy or replacement of a resource whose deletion protection
+// is enabled in the state. The replacement is detected from the changes of the string attributes requiring
+// it, since the attribute plan modifiers requiring it are not reported to the ModifyPlan of the resource.
+func planDeletionProtection(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+	kind string, replaceAttributes []string) {
+	if req.State.Raw.IsNull() {
+		return
+	}
+	var protection types.Bool
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribDeletionProtection), &protection)...)
+	if resp.Diagnostics.HasError() || !protection.ValueBool() {
+		return
+	}
+	if req.Plan.Raw.IsNull() {
+		resp.Diagnostics.AddError("Deletion protection is enabled",
+			fmt.Sprintf("The %s is planned to be destroyed, but deletion_protection is true. Set it to false and apply, then destroy it.", kind))
+		return
+	}
+	changed := plannedReplaceChanges(ctx, req, resp, replaceAttributes)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if len(changed) > 0 {
+		resp.Diagnostics.AddError("Deletion protection is enabled",
+			fmt.Sprintf("The %s is planned to be replaced because of changes to %s, but deletion_protection is true. "+
+				"Revert the changes, or set deletion_protection to false and apply first.", kind, strings.Join(changed, ", ")))
+	}
+}
+
+// plannedReplaceChanges returns the string attributes requiring the replacement of the resource
+// whose planned value differs from the state.
+func plannedReplaceChanges(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse,
+	replaceAttributes []string) []string {
+	var changed []string
+	for _, attribute := range replaceAttributes {
+		var planned, prior types.String
+		resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(attribute), &planned)...)
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribute), &prior)...)
+		if resp.Diagnostics.HasError() {
+			return nil
+		}
+		if !planned.Equal(prior) {
+			changed = append(changed, attribute)
+		}
+	}
+	return changed
+}
+
 func listRegions(ctx context.Context, client ngpc.Client) ([]ngpcv1.Region, error) {
 	regionsList := ngpcv1.RegionList{}
 	err := client.List(ctx, &regionsList)
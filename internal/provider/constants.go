@@ -2,4 +2,6 @@ package provider
 
 const (
 	Auth0AppName string = "NGPC UI"
+	// DefaultAPIServer is the ngpc api server used when neither api_server nor NGPC_APISERVER is set
+	DefaultAPIServer string = "https://spot.rackspace.com"
 )
@@ -0,0 +1,258 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	// DefaultJWKSCacheTTL is how long the fetched keys are used without revalidating them,
+	// unless the issuer sets a Cache-Control max-age.
+	DefaultJWKSCacheTTL = 1 * time.Hour
+	// jwksMinRefetchInterval limits how often an unknown kid triggers a refetch of the keys
+	jwksMinRefetchInterval = 10 * time.Second
+	// jwksHTTPTimeout bounds the time spent fetching keys from the issuer
+	jwksHTTPTimeout = 10 * time.Second
+	// maxJWKSSize is the maximum size of the JWKS document read from the issuer
+	maxJWKSSize = 1 << 20
+)
+
+// ErrKeyNotFound is returned when the key set does not contain the kid of the token
+var ErrKeyNotFound = errors.New("public key not found")
+
+// jwksCacheEntry holds the keys fetched from a single JWKS URL
+type jwksCacheEntry struct {
+	keys         map[string]crypto.PublicKey
+	fetchedAt    time.Time
+	expiresAt    time.Time
+	etag         string
+	lastModified string
+}
+
+// jwksCache caches the keys of the issuers so that they are not downloaded on every
+// configure of the provider. Stale entries are revalidated using conditional requests.
+type jwksCache struct {
+	mu         sync.Mutex
+	httpClient *http.Client
+	entries    map[string]*jwksCacheEntry
+}
+
+// defaultJWKSCache is shared by all provider instances of the process
+var defaultJWKSCache = newJWKSCache(&http.Client{Timeout: jwksHTTPTimeout})
+
+func newJWKSCache(httpClient *http.Client) *jwksCache {
+	return &jwksCache{
+		httpClient: httpClient,
+		entries:    map[string]*jwksCacheEntry{},
+	}
+}
+
+// publicKey returns the key with the given kid published at jwksURL. The keys are
+// refetched once if the kid is unknown, since the issuer may have rotated its keys.
+func (c *jwksCache) publicKey(ctx context.Context, jwksURL string, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.entries[jwksURL]
+	if entry == nil || time.Now().After(entry.expiresAt) {
+		if err := c.fetchLocked(ctx, jwksURL); err != nil {
+			if entry == nil {
+				return nil, err
+			}
+			// Stale keys are still better than failing when the issuer is briefly unavailable
+			tflog.Warn(ctx, "Failed to refresh JWKS, using cached keys", map[string]any{"jwksURL": jwksURL, "error": err.Error()})
+		}
+		entry = c.entries[jwksURL]
+	}
+	if key, found := entry.keys[kid]; found {
+		return key, nil
+	}
+	if time.Since(entry.fetchedAt) < jwksMinRefetchInterval {
+		return nil, fmt.Errorf("%w: kid %s", ErrKeyNotFound, kid)
+	}
+	tflog.Debug(ctx, "Unknown kid, refetching JWKS", map[string]any{"jwksURL": jwksURL, "kid": kid})
+	// Do not send validators, the issuer must return the current key set
+	entry.etag, entry.lastModified = "", ""
+	if err := c.fetchLocked(ctx, jwksURL); err != nil {
+		return nil, err
+	}
+	if key, found := c.entries[jwksURL].keys[kid]; found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: kid %s", ErrKeyNotFound, kid)
+}
+
+// fetchLocked downloads the key set, or revalidates the cached one if the issuer
+// supports conditional requests.
+func (c *jwksCache) fetchLocked(ctx context.Context, jwksURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURL, nil)
+	if err != nil {
+		return fmt.Errorf("error creating JWKS request: %w", err)
+	}
+	entry := c.entries[jwksURL]
+	if entry != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error fetching public keys from issuer: %w", err)
+	}
+	defer response.Body.Close()
+
+	now := time.Now()
+	if response.StatusCode == http.StatusNotModified && entry != nil {
+		tflog.Debug(ctx, "JWKS not modified", map[string]any{"jwksURL": jwksURL})
+		entry.fetchedAt = now
+		entry.expiresAt = now.Add(jwksCacheTTL(response.Header))
+		return nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("error fetching public keys from issuer: unexpected status %s", response.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxJWKSSize))
+	if err != nil {
+		return fmt.Errorf("error reading response body: %w", err)
+	}
+	keys, err := parseJWKS(ctx, body)
+	if err != nil {
+		return err
+	}
+	tflog.Debug(ctx, "Fetched JWKS", map[string]any{"jwksURL": jwksURL, "keys": len(keys)})
+	c.entries[jwksURL] = &jwksCacheEntry{
+		keys:         keys,
+		fetchedAt:    now,
+		expiresAt:    now.Add(jwksCacheTTL(response.Header)),
+		etag:         response.Header.Get("ETag"),
+		lastModified: response.Header.Get("Last-Modified"),
+	}
+	return nil
+}
+
+// jwksCacheTTL returns the max-age of the Cache-Control header if set, otherwise DefaultJWKSCacheTTL
+func jwksCacheTTL(header http.Header) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(directive), "max-age="); found {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return DefaultJWKSCacheTTL
+}
+
+// jsonWebKey is a single key of a JWKS document, only the members needed for
+// RSA and EC signature verification are decoded.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the signing keys of the JWKS document by kid. Keys of unsupported types
+// or which can not be decoded are skipped, so that a new kind of key published by the issuer
+// does not break the verification with the other keys.
+func parseJWKS(ctx context.Context, data []byte) (map[string]crypto.PublicKey, error) {
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return nil, fmt.Errorf("error parsing JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaPublicKey()
+		case "EC":
+			key, err = jwk.ecPublicKey()
+		default:
+			err = fmt.Errorf("unsupported key type %q", jwk.Kty)
+		}
+		if err != nil {
+			tflog.Debug(ctx, "Skipping JWKS key", map[string]any{"kid": jwk.Kid, "error": err.Error()})
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("error parsing JWKS: no usable signing keys found")
+	}
+	return keys, nil
+}
+
+func (jwk jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := fromBase64URL(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key modulus: %v", err)
+	}
+	e, err := fromBase64URL(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key exponent: %v", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func (jwk jsonWebKey) ecPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch jwk.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+	x, err := fromBase64URL(jwk.X)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key x coordinate: %v", err)
+	}
+	y, err := fromBase64URL(jwk.Y)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding public key y coordinate: %v", err)
+	}
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("public key is not on the curve")
+	}
+	return key, nil
+}
@@ -0,0 +1,171 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+)
+
+func rsaJWK(t *testing.T, kid string) map[string]string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(t *testing.T, kid string, curve elliptic.Curve, crv string) map[string]string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": crv,
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func jwksDocument(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseJWKS(t *testing.T) {
+	offCurve := ecJWK(t, "off-curve", elliptic.P256(), "P-256")
+	offCurve["y"] = offCurve["x"]
+	encryption := rsaJWK(t, "enc")
+	encryption["use"] = "enc"
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKids []string
+		wantErr  bool
+	}{
+		{
+			name: "rsa and ec keys",
+			data: jwksDocument(t,
+				rsaJWK(t, "rsa"),
+				ecJWK(t, "p256", elliptic.P256(), "P-256"),
+				ecJWK(t, "p384", elliptic.P384(), "P-384"),
+				ecJWK(t, "p521", elliptic.P521(), "P-521"),
+			),
+			wantKids: []string{"p256", "p384", "p521", "rsa"},
+		},
+		{
+			name: "unsupported key type is skipped",
+			data: jwksDocument(t,
+				rsaJWK(t, "rsa"),
+				map[string]string{"kty": "OKP", "kid": "ed25519", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			),
+			wantKids: []string{"rsa"},
+		},
+		{
+			name: "unsupported curve is skipped",
+			data: jwksDocument(t,
+				ecJWK(t, "p256", elliptic.P256(), "P-256"),
+				map[string]string{"kty": "EC", "kid": "secp256k1", "crv": "secp256k1", "x": "AQ", "y": "AQ"},
+			),
+			wantKids: []string{"p256"},
+		},
+		{
+			name:     "point not on the curve is skipped",
+			data:     jwksDocument(t, rsaJWK(t, "rsa"), offCurve),
+			wantKids: []string{"rsa"},
+		},
+		{
+			name:     "encryption keys and keys without kid are skipped",
+			data:     jwksDocument(t, rsaJWK(t, "rsa"), encryption, rsaJWK(t, "")),
+			wantKids: []string{"rsa"},
+		},
+		{
+			name:    "no usable keys",
+			data:    jwksDocument(t, map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}),
+			wantErr: true,
+		},
+		{
+			name:    "empty key set",
+			data:    []byte(`{"keys":[]}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			data:    []byte(`{"keys":`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseJWKS(context.Background(), tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got keys %v", keys)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var kids []string
+			for kid := range keys {
+				kids = append(kids, kid)
+			}
+			sort.Strings(kids)
+			if len(kids) != len(tt.wantKids) {
+				t.Fatalf("got kids %v, want %v", kids, tt.wantKids)
+			}
+			for i := range kids {
+				if kids[i] != tt.wantKids[i] {
+					t.Fatalf("got kids %v, want %v", kids, tt.wantKids)
+				}
+			}
+		})
+	}
+}
+
+func TestParseJWKSKeyValues(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := jwksDocument(t, map[string]string{
+		"kty": "EC",
+		"kid": "p384",
+		"crv": "P-384",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	})
+	keys, err := parseJWKS(context.Background(), data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, ok := keys["p384"].(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatalf("got key of type %T, want *ecdsa.PublicKey", keys["p384"])
+	}
+	if !parsed.Equal(&key.PublicKey) {
+		t.Fatal("parsed key does not match the generated key")
+	}
+}
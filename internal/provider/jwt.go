@@ -1,20 +1,19 @@
 package provider
 
 import (
-	"crypto/rsa"
+	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"math/big"
-	"net/http"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrOrgIDNotFound is returned when the token does not carry the org_id claim
+var ErrOrgIDNotFound = errors.New("org_id not found")
+
 type RxtSpotToken struct {
 	token       string
 	parsedToken *jwt.Token
@@ -39,7 +38,7 @@ func (j *RxtSpotToken) GetOrgID() (string, error) {
 			return "", errors.New("org_id is not of string type")
 		}
 	} else {
-		return "", errors.New("org_id not found")
+		return "", ErrOrgIDNotFound
 	}
 }
 
@@ -66,38 +65,81 @@ func (j *RxtSpotToken) IsEmailVerified() bool {
 	return true
 }
 
-func (j *RxtSpotToken) IsValidSignature() (bool, error) {
-	var err error
-	var issuer string
-	if issuer, err = j.claims.GetIssuer(); err != nil {
-		return false, fmt.Errorf("failed to get issuer: %w", err)
+// IsMachineToken returns true if the token is issued to a machine to machine
+// application using the client credentials grant
+func (j *RxtSpotToken) IsMachineToken() bool {
+	if val, found := j.claims["gty"]; found {
+		if grantType, ok := val.(string); ok {
+			return grantType == "client-credentials"
+		}
 	}
-	var ok bool
+	return false
+}
+
+// tokenVerification holds the expected issuer and audiences of the token along with
+// the JWKS URL of the issuer, as published in its OIDC discovery document.
+type tokenVerification struct {
+	issuer string
+	// keySource provides the keys of the issuer, either fetched from its JWKS URL or pinned in the provider config
+	keySource verificationKeySource
+	// audiences the token may be issued for, aud is not checked if empty
+	audiences []string
+}
+
+// IsValidSignature verifies the signature of the token using the keys of the issuer,
+// along with the iss and aud claims.
+func (j *RxtSpotToken) IsValidSignature(ctx context.Context, verification tokenVerification) (bool, error) {
+	// kid is optional for pinned PEM keys, which are all tried
 	var kid string
-	if kidIface, found := j.parsedToken.Header["kid"]; !found {
-		return false, errors.New("kid not found in token header")
-	} else if kid, ok = kidIface.(string); !ok {
-		return false, errors.New("kid from token header is not a string")
+	if kidIface, found := j.parsedToken.Header["kid"]; found {
+		var ok bool
+		if kid, ok = kidIface.(string); !ok {
+			return false, errors.New("kid from token header is not a string")
+		}
 	}
 
-	// jwks URL: https://cloudspaces.us.auth0.com/.well-known/jwks.json
-	jwksURL := fmt.Sprintf("%s.well-known/jwks.json", issuer)
-	publicKey, err := j.getIssuerPublicKey(jwksURL, kid)
+	publicKeys, err := verification.keySource.publicKeys(ctx, kid)
 	if err != nil {
-		return false, fmt.Errorf("error getting public key from issuer: %v", err)
+		return false, fmt.Errorf("error getting public key from %s: %v", verification.keySource, err)
 	}
 
-	_, err = jwt.Parse(j.token, func(token *jwt.Token) (interface{}, error) {
-		return publicKey, nil
-	})
-
+	var parsedToken *jwt.Token
+	for _, publicKey := range publicKeys {
+		parsedToken, err = jwt.Parse(j.token, func(token *jwt.Token) (interface{}, error) {
+			return publicKey, nil
+		}, jwt.WithValidMethods(supportedSigningMethods), jwt.WithIssuer(verification.issuer))
+		if err == nil {
+			break
+		}
+	}
 	if err != nil {
-		return false, fmt.Errorf("error verifying JWT signature: %v", err)
+		return false, fmt.Errorf("error verifying JWT signature using %s: %v", verification.keySource, err)
+	}
+	if len(verification.audiences) > 0 {
+		audiences, err := parsedToken.Claims.GetAudience()
+		if err != nil {
+			return false, fmt.Errorf("failed to get audience: %w", err)
+		}
+		if !containsAny(audiences, verification.audiences) {
+			return false, fmt.Errorf("token audience %v does not match any of the expected audiences %v", []string(audiences), verification.audiences)
+		}
 	}
 
 	return true, nil
 }
 
+// supportedSigningMethods are the signing algorithms of the RSA and EC keys accepted for the token
+var supportedSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+func containsAny(slice []string, values []string) bool {
+	for _, val := range values {
+		if StrSliceContains(slice, val) {
+			return true
+		}
+	}
+	return false
+}
+
 func fromBase64URL(s string) ([]byte, error) {
 	// Replace '-' with '+' and '_' with '/' to convert base64url to base64
 	s = strings.ReplaceAll(s, "-", "+")
@@ -117,47 +159,3 @@ func fromBase64URL(s string) ([]byte, error) {
 	}
 	return data, nil
 }
-
-func (j *RxtSpotToken) getIssuerPublicKey(url string, kid string) (*rsa.PublicKey, error) {
-	response, err := http.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching public keys from issuer: %v", err)
-	}
-	defer response.Body.Close()
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %v", err)
-	}
-
-	// Parse JSON response to extract public keys
-	var jwks struct {
-		Keys []struct {
-			Kty string `json:"kty"`
-			Kid string `json:"kid"`
-			N   string `json:"n"`
-			E   string `json:"e"`
-		} `json:"keys"`
-	}
-	if err := json.Unmarshal(body, &jwks); err != nil {
-		return nil, err
-	}
-
-	// Find the RSA public key
-	for _, key := range jwks.Keys {
-		if key.Kty == "RSA" && key.Kid == kid {
-			n, err := fromBase64URL(key.N)
-			if err != nil {
-				return nil, fmt.Errorf("error decoding public key modulus: %v", err)
-			}
-			e, err := fromBase64URL(key.E)
-			if err != nil {
-				return nil, fmt.Errorf("error decoding public key exponent: %v", err)
-			}
-			return &rsa.PublicKey{
-				N: new(big.Int).SetBytes(n),
-				E: int(new(big.Int).SetBytes(e).Int64()),
-			}, nil
-		}
-	}
-	return nil, errors.New("public key not found")
-}
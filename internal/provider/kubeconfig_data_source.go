@@ -6,16 +6,15 @@ import (
 	_ "embed"
 	"fmt"
 	"html/template"
-	"os"
 
 	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
 	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
-	"github.com/cenkalti/backoff/v4"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/rackerlabs/terraform-provider-spot/internal/provider/datasource_kubeconfig"
+	"golang.org/x/oauth2"
 	ktypes "k8s.io/apimachinery/pkg/types"
 )
 
@@ -33,7 +32,10 @@ func NewKubeconfigDataSource() datasource.DataSource {
 
 type kubeconfigDataSource struct {
 	ngpcClient      ngpc.Client
+	namespace       string
 	organizerClient *ngpc.OrganizerClient
+	tokenSource     oauth2.TokenSource
+	orgID           string
 }
 
 func (d *kubeconfigDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -58,11 +60,23 @@ func (d *kubeconfigDataSource) Configure(ctx context.Context, req datasource.Con
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
+	d.namespace = spotProviderData.namespace
 	d.organizerClient = spotProviderData.organizerClient
+	d.tokenSource = spotProviderData.tokenSource
+	d.orgID = spotProviderData.orgID
 }
 
 func (d *kubeconfigDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_kubeconfig.KubeconfigModel
 
 	// Read Terraform configuration data into the model
@@ -76,11 +90,7 @@ func (d *kubeconfigDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := d.namespace
 	tflog.Debug(ctx, "Getting cloudspace", map[string]interface{}{"name": name, "namespace": namespace})
 	cloudspace := &ngpcv1.CloudSpace{}
 	err = d.ngpcClient.Get(ctx, ktypes.NamespacedName{
@@ -95,9 +105,8 @@ func (d *kubeconfigDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		// If APIServerEndpoint is empty then probably cloudspace is not ready
 		// TODO: Use user provided timeouts; see cloudspace resource for reference
 		tflog.Debug(ctx, "Waiting for cloudspace to be ready", map[string]interface{}{"name": name, "namespace": namespace})
-		maxRetries := uint64(30)
-		backoffStrategy := backoff.WithMaxRetries(backoff.NewConstantBackOff(DefaultRefreshInterval), maxRetries)
-		err := backoff.Retry(waitForCloudSpaceControlPlaneReady(ctx, d.ngpcClient, name, namespace), backoffStrategy)
+		err := waitFor(ctx, d.ngpcClient, DefaultKubeconfigReadyTimeout,
+			waitForCloudSpaceControlPlaneReady(d.ngpcClient, name, namespace), cloudSpaceScope(name, namespace))
 		if err != nil {
 			resp.Diagnostics.AddError("Cloudspace is not ready", err.Error())
 			return
@@ -108,12 +117,17 @@ func (d *kubeconfigDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		resp.Diagnostics.AddError("Failed to get auth0 client apps", err.Error())
 		return
 	}
-	// TODO: Use spotProviderData to get token
-	token := os.Getenv("RXTSPOT_TOKEN")
-	if token == "" {
-		resp.Diagnostics.AddError("Missing authentication token", "Set RXTSPOT_TOKEN environment variable")
+	if d.tokenSource == nil {
+		resp.Diagnostics.AddError("Missing authentication token", "Provider is not configured with an authentication token")
+		return
+	}
+	// Get the current token from the token source, it is refreshed if it is about to expire
+	accessToken, err := d.tokenSource.Token()
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to get access token", err.Error())
 		return
 	}
+	token := accessToken.AccessToken
 	kubeconfigVars := KubeconfigVars{
 		User:                  "ngpc-user",
 		Token:                 token,
@@ -134,10 +148,9 @@ func (d *kubeconfigDataSource) Read(ctx context.Context, req datasource.ReadRequ
 		resp.Diagnostics.AddError("Failed to get oidc client id or issuer url", "Please check if client app is created in Auth0")
 		return
 	}
-	// TODO: Use spotProviderData to get the value
-	kubeconfigVars.OrgID = os.Getenv("RXTSPOT_ORG_ID")
+	kubeconfigVars.OrgID = d.orgID
 	if kubeconfigVars.OrgID == "" {
-		resp.Diagnostics.AddError("Missing organization id", "Set RXTSPOT_ORG_ID environment variable")
+		resp.Diagnostics.AddError("Missing organization id", "Provider is not configured with an organization id")
 		return
 	}
 	orgName, err := FindOrgName(ctx, d.organizerClient, token, kubeconfigVars.OrgID)
@@ -28,6 +28,7 @@ func NewOndemandnodepoolDataSource() datasource.DataSource {
 
 type ondemandnodepoolDataSource struct {
 	ngpcClient ngpc.Client
+	namespace  string
 }
 
 func (d *ondemandnodepoolDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -58,10 +59,20 @@ func (d *ondemandnodepoolDataSource) Configure(ctx context.Context, req datasour
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
+	d.namespace = spotProviderData.namespace
 }
 
 func (d *ondemandnodepoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_ondemandnodepool.OndemandnodepoolModel
 
 	// Read Terraform configuration data into the model
@@ -72,15 +83,11 @@ func (d *ondemandnodepoolDataSource) Read(ctx context.Context, req datasource.Re
 	}
 
 	name := data.Name.ValueString()
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace from env", err.Error())
-		return
-	}
+	namespace := d.namespace
 	// Read API call logic
 	tflog.Info(ctx, "Getting ondemandnodepool", map[string]any{"name": name, "namespace": namespace})
 	onDemandNodePool := &ngpcv1.OnDemandNodePool{}
-	err = d.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, onDemandNodePool)
+	err := d.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, onDemandNodePool)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get ondemandnodepool", err.Error())
 		return
@@ -14,8 +14,10 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/rackerlabs/terraform-provider-spot/internal/provider/resource_ondemandnodepool"
 )
@@ -33,6 +35,8 @@ func NewOndemandnodepoolResource() resource.Resource {
 
 type ondemandnodepoolResource struct {
 	ngpcClient ngpc.Client
+	namespace  string
+	budget     spendBudget
 }
 
 func (r *ondemandnodepoolResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -57,10 +61,21 @@ func (r *ondemandnodepoolResource) Configure(ctx context.Context, req resource.C
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	r.ngpcClient = spotProviderData.ngpcClient
+	r.namespace = spotProviderData.namespace
+	r.budget = spotProviderData.budget
 }
 
 func (r *ondemandnodepoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_ondemandnodepool.OndemandnodepoolModel
 
 	// Read Terraform plan data into the model
@@ -70,16 +85,12 @@ func (r *ondemandnodepoolResource) Create(ctx context.Context, req resource.Crea
 		return
 	}
 
-	name, err := generateRandomUUID()
+	name, err := newNodePoolName(data.Name, data.NamePrefix)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate random UUID", err.Error())
-		return
-	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
+		resp.Diagnostics.AddError("Failed to generate name", err.Error())
 		return
 	}
+	namespace := r.namespace
 
 	tflog.Debug(ctx, "Creating ondemandnodepool", map[string]any{"name": name, "namespace": namespace})
 
@@ -150,25 +161,45 @@ func (r *ondemandnodepoolResource) Create(ctx context.Context, req resource.Crea
 	}
 
 	tflog.Debug(ctx, "Creating ondemandnodepool", map[string]any{"name": onDemandNodePool.ObjectMeta.Name})
-	err = r.ngpcClient.Create(ctx, onDemandNodePool)
+	err = r.ngpcClient.Create(ctx, onDemandNodePool, client.FieldOwner(fieldManager))
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to create nodepool", err.Error())
 		return
 	}
 	tflog.Debug(ctx, "Created ondemandnodepool", map[string]any{"name": onDemandNodePool.ObjectMeta.Name})
+	// Node pools planned after this one count it as existing from now on
+	r.budget.planned.markCreated("ondemandnodepool", name, data.EstimatedHourlyCost.ValueFloat64())
 	resp.Diagnostics.Append(setOnDemandNodePoolState(ctx, onDemandNodePool, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 	data.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	if data.EstimatedHourlyCost.IsUnknown() || data.EstimatedMonthlyCost.IsUnknown() {
+		r.refreshEstimatedCosts(ctx, &data)
+	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(onDemandNodePool.ObjectMeta.ResourceVersion))...)
+	// The name of the replaced node pool is only used during plan
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyReplacedName, nil)...)
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 	tflog.Debug(ctx, "Updated local state by getting remote api object", map[string]any{"name": onDemandNodePool.ObjectMeta.Name})
+
+	if data.WaitForNodes.ValueBool() {
+		createTimeout, diags := data.Timeouts.Create(ctx, DefaultNodePoolCreateTimeout)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+		resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, data.CloudspaceName.ValueString(), createTimeout)...)
+	}
 }
 
 func (r *ondemandnodepoolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if !checkProviderConfiguredForRead(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_ondemandnodepool.OndemandnodepoolModel
 
 	// Read Terraform prior state data into the model
@@ -180,15 +211,18 @@ func (r *ondemandnodepoolResource) Read(ctx context.Context, req resource.ReadRe
 
 	// Read API call logic
 	name := data.Name.ValueString()
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	tflog.Info(ctx, "Getting ondemandnodepool", map[string]any{"name": name, "namespace": namespace})
 	ondemandnodepool := &ngpcv1.OnDemandNodePool{}
-	err = r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, ondemandnodepool)
+	err := r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, ondemandnodepool)
+	if apierrors.IsNotFound(err) {
+		// Deleted outside of terraform, removing it from the state makes terraform plan a recreate
+		resp.Diagnostics.AddWarning("Ondemandnodepool not found",
+			fmt.Sprintf("Ondemandnodepool %s no longer exists and is removed from the state", name))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get ondemandnodepool", err.Error())
 		return
@@ -198,6 +232,14 @@ func (r *ondemandnodepoolResource) Read(ctx context.Context, req resource.ReadRe
 		return
 	}
 	data.LastUpdated = types.StringNull()
+	r.refreshEstimatedCosts(ctx, &data)
+	if data.DeletionProtection.IsNull() {
+		// Not known after an import
+		data.DeletionProtection = types.BoolValue(false)
+	}
+	if data.WaitForNodes.IsNull() {
+		data.WaitForNodes = types.BoolValue(false)
+	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(ondemandnodepool.ObjectMeta.ResourceVersion))...)
 	tflog.Debug(ctx, "Updating local state", map[string]any{"spec": data})
 	// Save updated data into Terraform state
@@ -205,6 +247,10 @@ func (r *ondemandnodepoolResource) Read(ctx context.Context, req resource.ReadRe
 }
 
 func (r *ondemandnodepoolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var plan, state resource_ondemandnodepool.OndemandnodepoolModel
 
 	// Read Terraform plan data into the model
@@ -217,13 +263,8 @@ func (r *ondemandnodepoolResource) Update(ctx context.Context, req resource.Upda
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	// TODO: Find the difference between state and plan and update only the changed fields using patch
 	name := plan.Name.ValueString()
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	// Get the latest version of the resource before updating
 	// We need to get the latest version to ensure we have the most up-to-date resource version
@@ -231,7 +272,7 @@ func (r *ondemandnodepoolResource) Update(ctx context.Context, req resource.Upda
 	// because other controllers may have modified the resource between our read and update
 	tflog.Debug(ctx, "Getting latest version of ondemandnodepool", map[string]any{"name": name})
 	latest := &ngpcv1.OnDemandNodePool{}
-	err = r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, latest)
+	err := r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, latest)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get latest version of ondemandnodepool", err.Error())
 		return
@@ -284,27 +325,18 @@ func (r *ondemandnodepoolResource) Update(ctx context.Context, req resource.Upda
 		taints = nil
 	}
 
-	ondemandnodepool := &ngpcv1.OnDemandNodePool{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "OnDemandNodePool",
-			APIVersion: "ngpc.rxt.io/v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			ResourceVersion: latest.ResourceVersion,
-		},
-		Spec: ngpcv1.OnDemandNodePoolSpec{
-			ServerClass:       plan.ServerClass.ValueString(),
-			Desired:           int(plan.DesiredServerCount.ValueInt64()),
-			CloudSpace:        plan.CloudspaceName.ValueString(),
-			CustomLabels:      labels,
-			CustomAnnotations: annotations,
-			CustomTaints:      taints,
-		},
-	}
-	tflog.Debug(ctx, "Updating ondemandnodepool", map[string]any{"name": ondemandnodepool.ObjectMeta.Name})
-	err = r.ngpcClient.Update(ctx, ondemandnodepool)
+	// Only the fields managed by terraform are changed, the patch leaves the fields
+	// managed by the backend as they are. If the ondemandnodepool is changed in between,
+	// the fields are applied to its latest version again.
+	tflog.Debug(ctx, "Updating ondemandnodepool", map[string]any{"name": name})
+	ondemandnodepool, err := updateObject(ctx, r.ngpcClient, latest, func(pool *ngpcv1.OnDemandNodePool) {
+		pool.Spec.ServerClass = plan.ServerClass.ValueString()
+		pool.Spec.Desired = int(plan.DesiredServerCount.ValueInt64())
+		pool.Spec.CloudSpace = plan.CloudspaceName.ValueString()
+		pool.Spec.CustomLabels = labels
+		pool.Spec.CustomAnnotations = annotations
+		pool.Spec.CustomTaints = taints
+	})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to update ondemandnodepool", err.Error())
 		return
@@ -316,11 +348,47 @@ func (r *ondemandnodepoolResource) Update(ctx context.Context, req resource.Upda
 	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(ondemandnodepool.ObjectMeta.ResourceVersion))...)
 	state.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	state.DeletionProtection = plan.DeletionProtection
+	state.WaitForNodes = plan.WaitForNodes
+	state.Timeouts = plan.Timeouts
+	state.EstimatedHourlyCost = plan.EstimatedHourlyCost
+	state.EstimatedMonthlyCost = plan.EstimatedMonthlyCost
+	if state.EstimatedHourlyCost.IsUnknown() || state.EstimatedMonthlyCost.IsUnknown() {
+		r.refreshEstimatedCosts(ctx, &state)
+	}
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+
+	if plan.WaitForNodes.ValueBool() {
+		updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultNodePoolUpdateTimeout)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+		resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, plan.CloudspaceName.ValueString(), updateTimeout)...)
+	}
+}
+
+// waitForNodes waits until the node pool has reserved its nodes. A timeout is reported as a warning listing
+// the pending allocations of the node pool, the node pool itself is created or updated.
+func (r *ondemandnodepoolResource) waitForNodes(ctx context.Context, name string, namespace string, cloudspaceName string, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+	tflog.Info(ctx, "Waiting for the nodes of the ondemandnodepool", map[string]any{"name": name})
+	err := waitFor(ctx, r.ngpcClient, timeout,
+		waitForOnDemandNodePoolNodes(r.ngpcClient, name, namespace),
+		onDemandNodePoolScope(name, namespace))
+	if err != nil {
+		diags.AddWarning("Failed to wait for the nodes of the ondemandnodepool",
+			err.Error()+describePendingAllocations(ctx, r.ngpcClient, cloudspaceName, namespace, name))
+	}
+	return diags
 }
 
 func (r *ondemandnodepoolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_ondemandnodepool.OndemandnodepoolModel
 
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -329,13 +397,13 @@ func (r *ondemandnodepoolResource) Delete(ctx context.Context, req resource.Dele
 	}
 
 	name := data.Name.ValueString()
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
+	namespace := r.namespace
+	if !checkDeletionProtection(data.DeletionProtection, "on-demand node pool", name, &resp.Diagnostics) {
 		return
 	}
+
 	tflog.Info(ctx, "Deleting ondemandnodepool", map[string]any{"name": name, "namespace": namespace})
-	err = r.ngpcClient.Delete(ctx, &ngpcv1.OnDemandNodePool{
+	err := r.ngpcClient.Delete(ctx, &ngpcv1.OnDemandNodePool{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "OnDemandNodePool",
 			APIVersion: "ngpc.rxt.io/v1",
@@ -357,27 +425,83 @@ func (r *ondemandnodepoolResource) ImportState(ctx context.Context, req resource
 }
 
 func (r *ondemandnodepoolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	planDeletionProtection(ctx, req, resp, "on-demand node pool", nodePoolReplaceAttributes)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if req.Plan.Raw.IsNull() {
+		planNodePoolBudget(ctx, req, resp, r.ngpcClient, r.namespace, r.budget, "ondemandnodepool")
+		return
+	}
+
 	var serverClassVal types.String
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribServerClass), &serverClassVal)...)
-	if !serverClassVal.IsNull() && !serverClassVal.IsUnknown() {
+	var serverClass *ngpcv1.ServerClass
+	// Validation is skipped if the provider configuration is not known yet
+	if !serverClassVal.IsNull() && !serverClassVal.IsUnknown() && r.ngpcClient != nil {
 		serverClasssList, err := listServerClasses(ctx, r.ngpcClient)
 		if err != nil {
 			resp.Diagnostics.AddWarning("Failed to list server classes", err.Error())
 		} else {
-			var validServerClass bool
-			for _, serverClass := range serverClasssList {
-				if serverClass.Name == serverClassVal.ValueString() {
-					validServerClass = true
+			for i := range serverClasssList {
+				if serverClasssList[i].Name == serverClassVal.ValueString() {
+					serverClass = &serverClasssList[i]
 					break
 				}
 			}
-			if !validServerClass {
+			if serverClass == nil {
 				resp.Diagnostics.AddAttributeError(path.Root(attribServerClass), "Invalid value",
 					"The valid values should be read from the serverclasses data source.")
 				return
 			}
 		}
 	}
+
+	if r.ngpcClient == nil {
+		// The provider configuration is not known yet, the costs planned by the framework are kept
+		return
+	}
+
+	// The costs are estimated on apply if the server class or the number of servers are not known yet
+	var desiredVal types.Int64
+	resp.Diagnostics.Append(resp.Plan.GetAttribute(ctx, path.Root(attribDesiredServerCount), &desiredVal)...)
+	hourlyCost, monthlyCost := types.Float64Unknown(), types.Float64Unknown()
+	if serverClass != nil && !desiredVal.IsUnknown() {
+		hourlyCost, monthlyCost = onDemandNodePoolCosts(ctx, serverClass, int(desiredVal.ValueInt64()))
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribEstimatedHourlyCost), hourlyCost)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribEstimatedMonthlyCost), monthlyCost)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planNodePoolBudget(ctx, req, resp, r.ngpcClient, r.namespace, r.budget, "ondemandnodepool")
+}
+
+// onDemandNodePoolCosts estimates the costs of the node pool, they are null if the on-demand
+// price of the server class can not be used.
+func onDemandNodePoolCosts(ctx context.Context, serverClass *ngpcv1.ServerClass, desired int) (types.Float64, types.Float64) {
+	hourlyCost, err := onDemandNodePoolHourlyCost(serverClass, desired)
+	if err != nil {
+		tflog.Warn(ctx, "Failed to estimate the costs of the ondemandnodepool", map[string]any{"error": err.Error()})
+		return types.Float64Null(), types.Float64Null()
+	}
+	return estimatedCosts(hourlyCost)
+}
+
+// refreshEstimatedCosts estimates the costs of the node pool from the current on-demand price of its
+// server class. Known costs are kept if the server class can not be read.
+func (r *ondemandnodepoolResource) refreshEstimatedCosts(ctx context.Context, model *resource_ondemandnodepool.OndemandnodepoolModel) {
+	serverClass := &ngpcv1.ServerClass{}
+	err := r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: model.ServerClass.ValueString()}, serverClass)
+	if err != nil {
+		tflog.Warn(ctx, "Failed to get the serverclass of the ondemandnodepool", map[string]any{"error": err.Error()})
+		if model.EstimatedHourlyCost.IsUnknown() || model.EstimatedMonthlyCost.IsUnknown() {
+			model.EstimatedHourlyCost, model.EstimatedMonthlyCost = types.Float64Null(), types.Float64Null()
+		}
+		return
+	}
+	model.EstimatedHourlyCost, model.EstimatedMonthlyCost = onDemandNodePoolCosts(ctx, serverClass, int(model.DesiredServerCount.ValueInt64()))
 }
 
 func setOnDemandNodePoolState(ctx context.Context, ondemandnodepool *ngpcv1.OnDemandNodePool, state *resource_ondemandnodepool.OndemandnodepoolModel) diag.Diagnostics {
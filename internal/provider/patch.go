@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// fieldManager is the manager recorded in the managed fields of the objects changed by the provider
+	fieldManager = "terraform-provider-spot"
+	// maxConflictRetries is the number of times an update conflicting with a remote change
+	// is applied again to the latest version of the object.
+	maxConflictRetries = 5
+)
+
+// patchObject sends the difference between base and obj as a JSON merge patch, so fields
+// which are not changed by the provider, such as the ones managed by the backend, are left as is.
+// The resourceVersion of base is part of the patch, the patch fails with a conflict if the object
+// was changed since. On success obj holds the object returned by the API.
+func patchObject(ctx context.Context, c ngpc.Client, obj client.Object, base client.Object) error {
+	patch := client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{})
+	if data, err := patch.Data(obj); err == nil {
+		tflog.Debug(ctx, "Patching object", map[string]any{
+			"name":  obj.GetName(),
+			"patch": string(data),
+		})
+	}
+	return c.Patch(ctx, obj, patch, client.FieldOwner(fieldManager))
+}
+
+// updateObject patches the fields managed by terraform, which apply sets on a copy of base.
+// When the patch conflicts with a change made remotely since base was read, the latest object
+// is read and the fields are applied to it again, at most maxConflictRetries times.
+// It returns the object returned by the API.
+func updateObject[T client.Object](ctx context.Context, c ngpc.Client, base T, apply func(T)) (T, error) {
+	obj := base.DeepCopyObject().(T)
+	apply(obj)
+	err := patchObject(ctx, c, obj, base)
+	var firstRead T
+	for attempt := 1; apierrors.IsConflict(err) && attempt <= maxConflictRetries; attempt++ {
+		tflog.Warn(ctx, "Object was modified remotely, applying the changes to the latest version", map[string]any{
+			"name":    obj.GetName(),
+			"attempt": attempt,
+		})
+		latest := reflect.New(reflect.TypeOf(base).Elem()).Interface().(T)
+		if getErr := c.Get(ctx, client.ObjectKeyFromObject(base), latest); getErr != nil {
+			return obj, fmt.Errorf("failed to read the latest version after a conflict: %w", getErr)
+		}
+		if attempt == 1 {
+			firstRead = latest.DeepCopyObject().(T)
+		}
+		base = latest
+		obj = base.DeepCopyObject().(T)
+		apply(obj)
+		err = patchObject(ctx, c, obj, base)
+	}
+	if apierrors.IsConflict(err) {
+		return obj, conflictError(firstRead, base, err)
+	}
+	return obj, err
+}
+
+// conflictError explains the remote changes which kept conflicting with the update,
+// naming the fields changed between the reads and the managers owning the spec.
+func conflictError(firstRead client.Object, lastRead client.Object, err error) error {
+	var details []string
+	if !reflect.ValueOf(firstRead).IsNil() {
+		if changed := changedFields(firstRead, lastRead); len(changed) > 0 {
+			details = append(details, fmt.Sprintf("fields changed remotely meanwhile: %s", strings.Join(changed, ", ")))
+		}
+	}
+	if managers := specManagers(lastRead); len(managers) > 0 {
+		details = append(details, fmt.Sprintf("the spec is also managed by %s", strings.Join(managers, ", ")))
+	}
+	msg := fmt.Sprintf("%s kept being modified remotely, the update conflicted %d times", lastRead.GetName(), maxConflictRetries+1)
+	if len(details) > 0 {
+		msg = fmt.Sprintf("%s (%s)", msg, strings.Join(details, "; "))
+	}
+	return fmt.Errorf("%s, run terraform refresh and apply again: %w", msg, err)
+}
+
+// changedFields returns the paths of the fields which differ between two versions of an object,
+// bookkeeping metadata updated on every write is left out.
+func changedFields(before client.Object, after client.Object) []string {
+	data, err := client.MergeFrom(before).Data(after)
+	if err != nil {
+		return nil
+	}
+	var patch map[string]any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil
+	}
+	var fields []string
+	var walk func(prefix string, value map[string]any)
+	walk = func(prefix string, value map[string]any) {
+		for key, val := range value {
+			field := key
+			if prefix != "" {
+				field = prefix + "." + key
+			}
+			switch field {
+			case "metadata.resourceVersion", "metadata.managedFields", "metadata.generation":
+				continue
+			}
+			if nested, ok := val.(map[string]any); ok {
+				walk(field, nested)
+				continue
+			}
+			fields = append(fields, field)
+		}
+	}
+	walk("", patch)
+	sort.Strings(fields)
+	return fields
+}
+
+// specManagers returns the managers, other than the provider, which own fields of the spec
+// of the object, the most recent first.
+func specManagers(obj client.Object) []string {
+	entries := obj.GetManagedFields()
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Time == nil || entries[j].Time == nil {
+			return entries[j].Time == nil && entries[i].Time != nil
+		}
+		return entries[j].Time.Before(entries[i].Time)
+	})
+	var managers []string
+	for _, entry := range entries {
+		if entry.Manager == fieldManager || entry.Subresource != "" || entry.FieldsV1 == nil {
+			continue
+		}
+		if !strings.Contains(string(entry.FieldsV1.Raw), `"f:spec"`) {
+			continue
+		}
+		manager := fmt.Sprintf("%q", entry.Manager)
+		if entry.Time != nil {
+			manager = fmt.Sprintf("%s (%s at %s)", manager, strings.ToLower(string(entry.Operation)), entry.Time.UTC().Format(time.RFC3339))
+		}
+		managers = append(managers, manager)
+	}
+	return managers
+}
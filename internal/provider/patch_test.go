@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// conflictClient fails the first conflicts patches with a conflict. The object is changed remotely
+// before every read, which sets the remote key of its data to the number of the read.
+type conflictClient struct {
+	ngpc.Client
+	current   *corev1.ConfigMap
+	conflicts int
+	patches   int
+	gets      int
+	// lastPatch and fieldOwner are the data and the field manager of the last patch
+	lastPatch  string
+	fieldOwner string
+}
+
+func (c *conflictClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.patches++
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	c.lastPatch = string(data)
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	c.fieldOwner = patchOptions.FieldManager
+	if c.patches <= c.conflicts {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), errors.New("the object has been modified"))
+	}
+	c.current = obj.(*corev1.ConfigMap).DeepCopy()
+	c.current.ResourceVersion = fmt.Sprintf("%d", 100+c.patches)
+	c.current.DeepCopyInto(obj.(*corev1.ConfigMap))
+	return nil
+}
+
+func (c *conflictClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.gets++
+	c.current.Data["remote"] = fmt.Sprintf("%d", c.gets)
+	c.current.ResourceVersion = fmt.Sprintf("%d", c.gets)
+	c.current.DeepCopyInto(obj.(*corev1.ConfigMap))
+	return nil
+}
+
+// testConfigMap returns an object whose spec is managed by the provider and by two other managers
+func testConfigMap() *corev1.ConfigMap {
+	earlier := metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	later := metav1.NewTime(earlier.Add(time.Hour))
+	spec := &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:desired":{}}}`)}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "pool",
+			Namespace:       "org",
+			ResourceVersion: "1",
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{Manager: fieldManager, Operation: metav1.ManagedFieldsOperationUpdate, Time: &earlier, FieldsV1: spec},
+				{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, Time: &earlier, FieldsV1: spec},
+				{Manager: "autoscaler", Operation: metav1.ManagedFieldsOperationApply, Time: &later, FieldsV1: spec},
+				{Manager: "controller", Operation: metav1.ManagedFieldsOperationUpdate, Time: &later, FieldsV1: spec, Subresource: "status"},
+			},
+		},
+		Data: map[string]string{"remote": "0"},
+	}
+}
+
+func TestPatchObject(t *testing.T) {
+	fake := &conflictClient{current: testConfigMap()}
+	base := testConfigMap()
+	obj := base.DeepCopy()
+	obj.Data["managed"] = "terraform"
+	if err := patchObject(context.Background(), fake, obj, base); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"data":{"managed":"terraform"},"metadata":{"resourceVersion":"1"}}`; fake.lastPatch != want {
+		t.Errorf("got patch %s, want %s", fake.lastPatch, want)
+	}
+	if fake.fieldOwner != fieldManager {
+		t.Errorf("got field manager %q, want %q", fake.fieldOwner, fieldManager)
+	}
+	if obj.ResourceVersion != "101" {
+		t.Errorf("got resource version %q, want the one returned by the API", obj.ResourceVersion)
+	}
+}
+
+func TestUpdateObject(t *testing.T) {
+	apply := func(obj *corev1.ConfigMap) {
+		obj.Data["managed"] = "terraform"
+	}
+
+	t.Run("updated", func(t *testing.T) {
+		fake := &conflictClient{current: testConfigMap()}
+		obj, err := updateObject(context.Background(), fake, testConfigMap(), apply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if obj.Data["managed"] != "terraform" || fake.patches != 1 || fake.gets != 0 {
+			t.Errorf("got data %v after %d patches and %d gets, want the managed key after 1 patch", obj.Data, fake.patches, fake.gets)
+		}
+	})
+
+	t.Run("updated after a conflict", func(t *testing.T) {
+		fake := &conflictClient{current: testConfigMap(), conflicts: 1}
+		obj, err := updateObject(context.Background(), fake, testConfigMap(), apply)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fake.patches != 2 || fake.gets != 1 {
+			t.Errorf("got %d patches and %d gets, want 2 and 1", fake.patches, fake.gets)
+		}
+		if obj.Data["managed"] != "terraform" || obj.Data["remote"] != "1" {
+			t.Errorf("got data %v, want the managed key applied to the latest version", obj.Data)
+		}
+	})
+
+	t.Run("conflicting until the retries run out", func(t *testing.T) {
+		fake := &conflictClient{current: testConfigMap(), conflicts: maxConflictRetries + 1}
+		_, err := updateObject(context.Background(), fake, testConfigMap(), apply)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if fake.patches != maxConflictRetries+1 || fake.gets != maxConflictRetries {
+			t.Errorf("got %d patches and %d gets, want %d and %d", fake.patches, fake.gets, maxConflictRetries+1, maxConflictRetries)
+		}
+		if !apierrors.IsConflict(err) {
+			t.Errorf("error %v does not wrap the conflict", err)
+		}
+		for _, want := range []string{
+			fmt.Sprintf("the update conflicted %d times", maxConflictRetries+1),
+			"fields changed remotely meanwhile: data.remote;",
+			`the spec is also managed by "autoscaler" (apply at 2026-01-02T04:04:05Z), "kubectl" (update at 2026-01-02T03:04:05Z)`,
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("error %q does not contain %q", err, want)
+			}
+		}
+		if strings.Contains(err.Error(), "controller") || strings.Contains(err.Error(), `"`+fieldManager+`"`) {
+			t.Errorf("error %q names the status or the provider manager", err)
+		}
+	})
+}
+
+func TestChangedFields(t *testing.T) {
+	before := testConfigMap()
+	after := before.DeepCopy()
+	after.ResourceVersion = "2"
+	after.Generation = 3
+	after.Labels = map[string]string{"team": "spot"}
+	after.Data["remote"] = "1"
+	after.Data["added"] = "x"
+
+	got := changedFields(before, after)
+	want := []string{"data.added", "data.remote", "metadata.labels.team"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("changedFields() = %v, want %v", got, want)
+	}
+	if got := changedFields(before, before.DeepCopy()); len(got) != 0 {
+		t.Errorf("changedFields() of identical objects = %v, want none", got)
+	}
+}
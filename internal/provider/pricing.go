@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// hoursPerMonth is the average number of hours in a month, used to convert monthly prices to hourly ones
+const hoursPerMonth = 730
+
+// parsePrice parses a price of the API, which may be prefixed with the dollar sign
+func parsePrice(price string) (float64, error) {
+	value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(price), "$"))
+	if value == "" {
+		return 0, fmt.Errorf("price is empty")
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// marketPricePerHour returns the current spot market price of the server class in USD per hour
+func marketPricePerHour(serverClass *ngpcv1.ServerClass) (float64, error) {
+	price, err := parsePrice(serverClass.Status.SpotPricing.MarketPricePerHour)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the market price of serverclass %s: %w", serverClass.Name, err)
+	}
+	return price, nil
+}
+
+// onDemandPricePerHour returns the on-demand price of the server class in USD per hour,
+// converted from the pricing interval of the server class.
+func onDemandPricePerHour(serverClass *ngpcv1.ServerClass) (float64, error) {
+	pricing := serverClass.Spec.OnDemandPricing
+	price, err := parsePrice(pricing.Cost)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse the on-demand price of serverclass %s: %w", serverClass.Name, err)
+	}
+	switch strings.ToLower(strings.TrimSpace(pricing.Interval)) {
+	case "", "hour", "hourly", "hr":
+		return price, nil
+	case "month", "monthly", "mo":
+		return price / hoursPerMonth, nil
+	default:
+		return 0, fmt.Errorf("unsupported on-demand pricing interval %q of serverclass %s", pricing.Interval, serverClass.Name)
+	}
+}
+
+// roundBidPrice rounds a bid price up to the three decimal places accepted by the API,
+// so the bid is never below the price it was derived from.
+func roundBidPrice(price float64) float64 {
+	rounded := math.Ceil(math.Round(price*1e6)/1e3) / 1e3
+	return math.Max(rounded, 0.001)
+}
+
+// spotNodePoolHourlyCost returns the hourly cost of a spot node pool at its bid price, for its desired
+// number of servers or the maximum one when autoscaling is enabled.
+func spotNodePoolHourlyCost(bidPrice float64, desired int, autoscaling ngpcv1.AutoscalingSpec) float64 {
+	count := desired
+	if autoscaling.Enabled {
+		count = autoscaling.MaxNodes
+	}
+	return bidPrice * float64(count)
+}
+
+// onDemandNodePoolHourlyCost returns the hourly cost of an on-demand node pool for its desired number of servers
+func onDemandNodePoolHourlyCost(serverClass *ngpcv1.ServerClass, desired int) (float64, error) {
+	price, err := onDemandPricePerHour(serverClass)
+	if err != nil {
+		return 0, err
+	}
+	return price * float64(desired), nil
+}
+
+// nodePoolsHourlyCost returns the sum of the hourly costs of the spot and on-demand node pools
+func nodePoolsHourlyCost(ctx context.Context, ngpcClient ngpc.Client, nodePools []client.Object) (float64, error) {
+	var total float64
+	for _, nodePool := range nodePools {
+		cost, err := nodePoolHourlyCost(ctx, ngpcClient, nodePool)
+		if err != nil {
+			return 0, err
+		}
+		total += cost
+	}
+	return total, nil
+}
+
+// nodePoolHourlyCost returns the hourly cost of a spot or on-demand node pool,
+// the price of an on-demand node pool is read from its server class.
+func nodePoolHourlyCost(ctx context.Context, ngpcClient ngpc.Client, nodePool client.Object) (float64, error) {
+	switch pool := nodePool.(type) {
+	case *ngpcv1.SpotNodePool:
+		bidPrice, err := parsePrice(pool.Spec.BidPrice)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse the bid price of spotnodepool %s: %w", pool.Name, err)
+		}
+		return spotNodePoolHourlyCost(bidPrice, pool.Spec.Desired, pool.Spec.Autoscaling), nil
+	case *ngpcv1.OnDemandNodePool:
+		serverClass := &ngpcv1.ServerClass{}
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: pool.Spec.ServerClass}, serverClass)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get serverclass %s: %w", pool.Spec.ServerClass, err)
+		}
+		return onDemandNodePoolHourlyCost(serverClass, pool.Spec.Desired)
+	default:
+		return 0, fmt.Errorf("unexpected node pool type %T", nodePool)
+	}
+}
+
+// estimatedCosts returns the estimated_hourly_cost and estimated_monthly_cost attribute values
+// for the hourly cost, rounded to a hundredth of a cent and to a cent.
+func estimatedCosts(hourlyCost float64) (types.Float64, types.Float64) {
+	return types.Float64Value(math.Round(hourlyCost*1e4) / 1e4), types.Float64Value(math.Round(hourlyCost*hoursPerMonth*1e2) / 1e2)
+}
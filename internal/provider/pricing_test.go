@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"math"
+	"testing"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+)
+
+// testServerClass returns a server class with the given market price and on-demand pricing
+func testServerClass(marketPrice string, onDemandCost string, onDemandInterval string) *ngpcv1.ServerClass {
+	serverClass := &ngpcv1.ServerClass{}
+	serverClass.Name = "gp.vs1.medium-dfw"
+	serverClass.Status.SpotPricing.MarketPricePerHour = marketPrice
+	serverClass.Spec.OnDemandPricing.Cost = onDemandCost
+	serverClass.Spec.OnDemandPricing.Interval = onDemandInterval
+	return serverClass
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		price   string
+		want    float64
+		wantErr bool
+	}{
+		{"0.012", 0.012, false},
+		{"$0.012", 0.012, false},
+		{" $ 0.012 ", 0.012, false},
+		{"$146", 146, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"$", 0, true},
+		{"free", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePrice(tt.price)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePrice(%q) error = %v, wantErr %v", tt.price, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parsePrice(%q) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestRoundBidPrice(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  float64
+	}{
+		{0.012, 0.012},
+		{0.0121, 0.013},
+		{0.012001, 0.013},
+		{0.0120001, 0.012},
+		{0.003 * 1.1, 0.004},
+		{0.1 + 0.2, 0.3},
+		{0.0001, 0.001},
+		{0, 0.001},
+		{1.5, 1.5},
+	}
+	for _, tt := range tests {
+		if got := roundBidPrice(tt.price); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("roundBidPrice(%v) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestOnDemandPricePerHour(t *testing.T) {
+	tests := []struct {
+		name     string
+		cost     string
+		interval string
+		want     float64
+		wantErr  bool
+	}{
+		{"hourly", "$0.25", "hour", 0.25, false},
+		{"no interval", "0.25", "", 0.25, false},
+		{"monthly", "$146", "Month", 0.2, false},
+		{"unsupported interval", "$1", "week", 0, true},
+		{"invalid cost", "n/a", "hour", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := onDemandPricePerHour(testServerClass("", tt.cost, tt.interval))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("onDemandPricePerHour() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("onDemandPricePerHour() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,18 +2,25 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
 	"github.com/coreos/go-oidc"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
-	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"k8s.io/client-go/rest"
 
 	"github.com/rackerlabs/terraform-provider-spot/internal/provider/provider_spot"
 )
@@ -25,6 +32,24 @@ var _ provider.Provider = (*spotProvider)(nil)
 type SpotProviderData struct {
 	ngpcClient      ngpc.Client
 	organizerClient *ngpc.OrganizerClient
+	// tokenSource mints new access tokens using the refresh token before the token expires
+	tokenSource oauth2.TokenSource
+	// transportSettings holds the TLS and proxy settings of the provider, also used to reach cloudspaces
+	transportSettings *ngpcTransportSettings
+	// orgID is the organization id the token belongs to
+	orgID string
+	// namespace is the namespace of the organization in the Spot backend
+	namespace string
+	// budget holds the spend limits checked when planning node pools
+	budget spendBudget
+
+	// config and version are used to create the above on first use
+	config  provider_spot.SpotModel
+	version string
+	// configUnknown is true if the provider config has values not known during plan
+	configUnknown bool
+	initOnce      sync.Once
+	initDiags     diag.Diagnostics
 }
 
 // New creates Provider with given version
@@ -54,33 +79,94 @@ func (p *spotProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	ngpcAPIServer := os.Getenv("NGPC_APISERVER")
+	// Clients are created on first use by a resource or data source, hence validate and
+	// plan of configurations not using any of them work without credentials.
+	spotProviderData := &SpotProviderData{
+		budget:  newSpendBudget(config.Budget),
+		config:  config,
+		version: p.Version,
+	}
+	if !req.Config.Raw.IsFullyKnown() {
+		// The config depends on values known only after apply, for example a token created
+		// by another resource. Terraform configures the provider again once they are known.
+		tflog.Info(ctx, "Provider configuration contains unknown values, deferring client initialization")
+		spotProviderData.configUnknown = true
+	}
+	resp.ResourceData = spotProviderData
+	resp.DataSourceData = spotProviderData
+}
+
+// initialize creates the clients on the first call, subsequent calls return the diagnostics of the first one.
+// The clients stay nil if the provider configuration is not known yet.
+func (d *SpotProviderData) initialize(ctx context.Context) diag.Diagnostics {
+	d.initOnce.Do(func() {
+		if d.configUnknown {
+			return
+		}
+		d.initDiags = d.createClients(ctx)
+	})
+	return d.initDiags
+}
+
+func (d *SpotProviderData) createClients(ctx context.Context) diag.Diagnostics {
+	var diags diag.Diagnostics
+	config := d.config
+
+	ngpcAPIServer := stringValueOrEnv(config.ApiServer, "NGPC_APISERVER")
 	if ngpcAPIServer == "" {
-		ngpcAPIServer = "https://spot.rackspace.com"
+		ngpcAPIServer = DefaultAPIServer
 	} else {
 		tflog.Info(ctx, "Using provided ngpc api server", map[string]any{"ngpcAPIServer": ngpcAPIServer})
 	}
+	retryPolicy, retryDiags := newRetryPolicy(config.Retry)
+	diags.Append(retryDiags...)
+	if diags.HasError() {
+		return diags
+	}
+	insecure, err := boolValueOrEnv(config.Insecure, "RXTSPOT_INSECURE")
+	if err != nil {
+		diags.AddAttributeError(path.Root("insecure"), "Invalid insecure setting", err.Error())
+		return diags
+	}
+	transportSettings := &ngpcTransportSettings{
+		insecure: insecure,
+		caBundle: stringValueOrEnv(config.CaBundle, "RXTSPOT_CA_BUNDLE"),
+	}
+	// dev builds talk to local api servers with self signed certificates
+	insecure = insecure || d.version == "dev"
+	if insecure && transportSettings.caBundle != "" {
+		diags.AddAttributeError(path.Root("ca_bundle"), "Conflicting TLS settings",
+			"ca_bundle can not be used when insecure is enabled, unset one of them")
+		return diags
+	}
+	if httpProxy := stringValueOrEnv(config.HttpProxy, "RXTSPOT_HTTP_PROXY"); httpProxy != "" {
+		proxyURL, err := url.Parse(httpProxy)
+		if err != nil || proxyURL.Host == "" {
+			diags.AddAttributeError(path.Root("http_proxy"), "Invalid HTTP proxy",
+				fmt.Sprintf("%q is not a valid proxy URL, use a value like \"http://proxy.example.com:3128\"", httpProxy))
+			return diags
+		}
+		tflog.Debug(ctx, "Using HTTP proxy for ngpc api server", map[string]any{"proxyHost": proxyURL.Host})
+		transportSettings.proxyURL = proxyURL
+	}
 
 	var strRxtSpotToken string
-	var tokenStringVal basetypes.StringValue
-	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("token"), &tokenStringVal)...)
-	if resp.Diagnostics.HasError() {
-		return
-	}
+	tokenStringVal := config.Token
 
 	// Below "ngpcCfg" & "organizerClient" is used create a unauthenticated
 	// ngpc client to query the organizer for Auth0 client list.
-	ngpcCfg := ngpc.NewConfig(ngpcAPIServer, "", p.Version == "dev")
+	ngpcCfg := ngpc.NewConfig(ngpcAPIServer, "", insecure)
+	transportSettings.apply(ngpcCfg)
 	organizerClient := ngpc.NewOrganizerClient(ngpcCfg)
 	// get the refresh token from the user input
 	auth0ClientApps, err := organizerClient.GetAuth0Clients(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to get auth0 client apps", err.Error())
-		return
+		diags.AddError("Failed to get auth0 client apps", err.Error())
+		return diags
 	}
 	if organizerClient == nil {
-		resp.Diagnostics.AddError("Failed to create organizer client", "organizerClient is nil")
-		return
+		diags.AddError("Failed to create organizer client", "organizerClient is nil")
+		return diags
 	}
 
 	// get the auth0 client list from organizer
@@ -97,15 +183,28 @@ func (p *spotProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		}
 	}
 	if auth0ClientId == "" || auth0ClientURL == "" {
-		resp.Diagnostics.AddError("Failed to get auth0 client details", "auth0 clientId (or) clientURL is empty")
-		return
+		diags.AddError("Failed to get auth0 client details", "auth0 clientId (or) clientURL is empty")
+		return diags
 	}
 
 	// Create an OIDC provider
 	oidcProvider, err := oidc.NewProvider(ctx, auth0ClientURL)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create OIDC provider", err.Error())
-		return
+		diags.AddError("Failed to create OIDC provider", err.Error())
+		return diags
+	}
+	// The token is verified against the issuer and keys published in the discovery document
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := oidcProvider.Claims(&discovery); err != nil {
+		diags.AddError("Failed to read OIDC discovery document", err.Error())
+		return diags
+	}
+	if discovery.Issuer == "" || discovery.JWKSURI == "" {
+		diags.AddError("Failed to read OIDC discovery document", "issuer (or) jwks_uri is empty")
+		return diags
 	}
 	// Configure the OAuth2 client
 	oauth2Config := &oauth2.Config{
@@ -114,111 +213,254 @@ func (p *spotProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		Scopes:   []string{"openid", "profile", "email", "offline_access"},
 	}
 
-	// use the client address to get the access token
-	// and set it in the strRxtSpotToken var
-	if !tokenStringVal.IsNull() && !tokenStringVal.IsUnknown() {
-		strRxtSpotToken, err = GetAccessToken(ctx, oauth2Config, tokenStringVal.ValueString())
-		if err != nil {
-			resp.Diagnostics.AddError("error getting the access token", err.Error())
-			return
+	var minter tokenMinter
+	clientID := config.ClientId.ValueString()
+	if envClientID := os.Getenv("RXTSPOT_CLIENT_ID"); clientID == "" && envClientID != "" {
+		// Credentials set in the provider config take precedence over the environment
+		if (!tokenStringVal.IsNull() && !tokenStringVal.IsUnknown()) || (!config.TokenCommand.IsNull() && !config.TokenCommand.IsUnknown()) {
+			diags.AddWarning("RXTSPOT_CLIENT_ID environment variable is ignored",
+				"The token or token_command set in the provider config is used instead of the client credentials set in the environment.")
+		} else {
+			clientID = envClientID
 		}
-	} else {
-		rxtRefreshToken := os.Getenv("RXTSPOT_TOKEN")
-		if rxtRefreshToken == "" {
-			rxtSpotTokenFile, found := os.LookupEnv("RXTSPOT_TOKEN_FILE")
-			if !found {
-				resp.Diagnostics.AddError("Missing authentication token", "Set RXTSPOT_TOKEN or RXTSPOT_TOKEN_FILE environment variable")
-				return
-			}
-			tflog.Debug(ctx, "Reading authentication token from file", map[string]any{"rxtSpotTokenFile": rxtSpotTokenFile})
+	}
+	if !config.TokenCommand.IsNull() && !config.TokenCommand.IsUnknown() {
+		// Token is obtained from an external credential helper
+		var tokenCommand []string
+		diags.Append(config.TokenCommand.ElementsAs(ctx, &tokenCommand, false)...)
+		if diags.HasError() {
+			return diags
+		}
+		tokenCommandTimeout := DefaultTokenCommandTimeout
+		if timeout := config.TokenCommandTimeout.ValueString(); timeout != "" {
 			var err error
-			rxtRefreshToken, err = readFileUpToNBytes(rxtSpotTokenFile, 5120)
-			if err != nil {
-				resp.Diagnostics.AddError("Failed to read authentication token from file", err.Error())
-				return
+			tokenCommandTimeout, err = time.ParseDuration(timeout)
+			if err != nil || tokenCommandTimeout <= 0 {
+				diags.AddAttributeError(path.Root("token_command_timeout"), "Invalid token command timeout",
+					fmt.Sprintf("%q is not a valid positive duration, use a value like \"30s\"", timeout))
+				return diags
+			}
+		}
+		tflog.Debug(ctx, "Using token command authentication")
+		minter = &commandTokenMinter{
+			command: tokenCommand,
+			timeout: tokenCommandTimeout,
+			config:  oauth2Config,
+		}
+	} else if clientID != "" {
+		// Machine to machine authentication using the client credentials grant
+		clientSecret := stringValueOrEnv(config.ClientSecret, "RXTSPOT_CLIENT_SECRET")
+		if clientSecret == "" {
+			diags.AddError("Missing client secret", "Set client_secret or RXTSPOT_CLIENT_SECRET environment variable when client_id is set")
+			return diags
+		}
+		clientCredentialsConfig := &clientcredentials.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			TokenURL:     oidcProvider.Endpoint().TokenURL,
+		}
+		if audience := stringValueOrEnv(config.Audience, "RXTSPOT_AUDIENCE"); audience != "" {
+			clientCredentialsConfig.EndpointParams = url.Values{"audience": []string{audience}}
+		}
+		tflog.Debug(ctx, "Using client credentials authentication", map[string]any{"clientID": clientID})
+		minter = &clientCredentialsMinter{config: clientCredentialsConfig}
+	} else {
+		// use the token provided in the provider config, otherwise fallback to
+		// RXTSPOT_TOKEN or RXTSPOT_TOKEN_FILE environment variables
+		var rxtRefreshToken, rxtSpotTokenFile string
+		if !tokenStringVal.IsNull() && !tokenStringVal.IsUnknown() {
+			rxtRefreshToken = tokenStringVal.ValueString()
+		} else {
+			rxtRefreshToken = os.Getenv("RXTSPOT_TOKEN")
+			if rxtRefreshToken == "" {
+				var found bool
+				rxtSpotTokenFile, found = os.LookupEnv("RXTSPOT_TOKEN_FILE")
+				if !found {
+					diags.AddError("Missing authentication token", "Set RXTSPOT_TOKEN or RXTSPOT_TOKEN_FILE environment variable")
+					return diags
+				}
+				tflog.Debug(ctx, "Reading authentication token from file", map[string]any{"rxtSpotTokenFile": rxtSpotTokenFile})
+				var err error
+				rxtRefreshToken, err = readFileUpToNBytes(rxtSpotTokenFile, maxTokenFileSize)
+				if err != nil {
+					diags.AddError("Failed to read authentication token from file", err.Error())
+					return diags
+				}
 			}
 		}
 
-		strRxtSpotToken, err = GetAccessToken(ctx, oauth2Config, rxtRefreshToken)
-		if err != nil {
-			resp.Diagnostics.AddError("error getting the access token", err.Error())
-			return
+		refreshMinter := &refreshTokenMinter{
+			config:       oauth2Config,
+			refreshToken: rxtRefreshToken,
+		}
+		if config.PersistRotatedRefreshToken.ValueBool() {
+			if rxtSpotTokenFile == "" {
+				diags.AddAttributeWarning(path.Root("persist_rotated_refresh_token"), "Rotated refresh token will not be persisted",
+					"persist_rotated_refresh_token is effective only when the token is read from the file set in RXTSPOT_TOKEN_FILE environment variable")
+			} else {
+				refreshMinter.tokenFile = rxtSpotTokenFile
+			}
 		}
+		minter = refreshMinter
 	}
-	// Setting token in environment variable for other workflows like kubeconfig generation
-	// TODO: Use SpotProviderData to store all these variables
-	err = os.Setenv("RXTSPOT_TOKEN", strRxtSpotToken)
+
+	tokenSource := newSpotTokenSource(ctx, minter)
+	accessToken, err := tokenSource.Token()
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to set RXTSPOT_TOKEN in environment variable", err.Error())
-		return
+		diags.AddError("error getting the access token", err.Error())
+		return diags
 	}
+	strRxtSpotToken = accessToken.AccessToken
 
 	rxtSpotToken := NewRxtSpotToken(strRxtSpotToken)
 	if err := rxtSpotToken.Parse(); err != nil {
-		resp.Diagnostics.AddError("Failed to parse token", err.Error())
-		return
+		diags.AddError("Failed to parse token", err.Error())
+		return diags
 	}
 
-	expired, err := rxtSpotToken.IsExpired()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to check if token is expired", err.Error())
-		return
-	}
-	if expired {
-		resp.Diagnostics.AddError("Token is expired", "Please use a valid token")
-		return
-	}
+	// The token is still used to call the Spot backend, only its verification is skipped
+	if config.SkipCredentialsValidation.ValueBool() {
+		tflog.Warn(ctx, "Skipping validation of the credentials")
+	} else {
+		expired, err := rxtSpotToken.IsExpired()
+		if err != nil {
+			diags.AddError("Failed to check if token is expired", err.Error())
+			return diags
+		}
+		if expired {
+			diags.AddError("Token is expired", "Please use a valid token")
+			return diags
+		}
 
-	if !rxtSpotToken.IsEmailVerified() {
-		resp.Diagnostics.AddError("Email is not verified", "Please verify your email to use Spot services")
-		return
-	}
+		// Tokens issued to machine to machine applications do not carry email claims
+		if !rxtSpotToken.IsMachineToken() && !rxtSpotToken.IsEmailVerified() {
+			diags.AddError("Email is not verified", "Please verify your email to use Spot services")
+			return diags
+		}
 
-	isValidSignature, err := rxtSpotToken.IsValidSignature()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to check if token has valid signature", err.Error())
-		return
-	}
-	if !isValidSignature {
-		resp.Diagnostics.AddError("Token has invalid signature", "Please use a valid token")
-		return
+		verification := tokenVerification{
+			issuer:    discovery.Issuer,
+			keySource: &issuerKeySource{jwksURL: discovery.JWKSURI},
+		}
+		// Air-gapped runners may not reach the JWKS URL of the issuer, hence the keys can be pinned
+		if jwksFile := stringValueOrEnv(config.JwksFile, "RXTSPOT_JWKS_FILE"); jwksFile != "" {
+			keySource, err := newJWKSFileKeySource(ctx, jwksFile)
+			if err != nil {
+				diags.AddAttributeError(path.Root("jwks_file"), "Failed to read JWKS file", err.Error())
+				return diags
+			}
+			verification.keySource = keySource
+		} else if publicKeysPEM := stringValueOrEnv(config.PublicKeysPem, "RXTSPOT_PUBLIC_KEYS_PEM"); publicKeysPEM != "" {
+			keySource, err := newPEMKeySource(publicKeysPEM)
+			if err != nil {
+				diags.AddAttributeError(path.Root("public_keys_pem"), "Failed to parse public keys", err.Error())
+				return diags
+			}
+			verification.keySource = keySource
+		}
+		tflog.Debug(ctx, "Verifying token signature", map[string]any{"keySource": verification.keySource.String()})
+		// id_tokens are issued for the Auth0 application, access tokens for the requested audience
+		audience := stringValueOrEnv(config.Audience, "RXTSPOT_AUDIENCE")
+		if audience != "" {
+			verification.audiences = append(verification.audiences, audience)
+		}
+		if !rxtSpotToken.IsMachineToken() {
+			verification.audiences = append(verification.audiences, auth0ClientId)
+		}
+		isValidSignature, err := rxtSpotToken.IsValidSignature(ctx, verification)
+		if err != nil {
+			diags.AddError("Failed to check if token has valid signature", err.Error())
+			return diags
+		}
+		if !isValidSignature {
+			diags.AddError("Token has invalid signature", "Please use a valid token")
+			return diags
+		}
 	}
 	orgID, err := rxtSpotToken.GetOrgID()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get org_id from authentication token", err.Error())
-		return
+	if err != nil && !errors.Is(err, ErrOrgIDNotFound) {
+		diags.AddError("Failed to get org_id from authentication token", err.Error())
+		return diags
 	}
-	if err = os.Setenv("RXTSPOT_ORG_ID", orgID); err != nil {
-		resp.Diagnostics.AddError("Failed to set org_id in environment variable RXTSPOT_ORG_ID", err.Error())
-		return
+	configuredOrg := stringValueOrEnv(config.Organization, "RXTSPOT_ORGANIZATION")
+	if configuredOrg != "" && configuredOrg != orgID {
+		if rxtSpotToken.IsMachineToken() {
+			// Tokens issued to machine to machine applications may not carry org_id,
+			// and can not list organizations of a user, hence the organization must be an ID
+			tflog.Debug(ctx, "Using the configured organization for machine token", map[string]any{"org_id": configuredOrg})
+			orgID = configuredOrg
+		} else {
+			// Users belonging to several organizations select one by its ID or display name
+			resolvedOrgID, err := resolveOrgID(ctx, organizerClient, strRxtSpotToken, configuredOrg)
+			if err != nil {
+				diags.AddAttributeError(path.Root("organization"), "Failed to resolve organization", err.Error())
+				return diags
+			}
+			tflog.Debug(ctx, "Using the configured organization", map[string]any{"organization": configuredOrg, "org_id": resolvedOrgID})
+			orgID = resolvedOrgID
+		}
 	}
-	orgNamespace := findNamespaceFromID(orgID)
-	tflog.Debug(ctx, "Setting org_id in environment variable RXTSPOT_ORG_NS", map[string]any{"org_id": orgID, "orgNamespace": orgNamespace})
-	if err = os.Setenv("RXTSPOT_ORG_NS", orgNamespace); err != nil {
-		resp.Diagnostics.AddError("Failed to set org_id in environment variable RXTSPOT_ORG_NS", err.Error())
-		return
+	if orgID == "" {
+		diags.AddAttributeError(path.Root("organization"), "Missing organization",
+			"The token does not carry the org_id claim, set organization or RXTSPOT_ORGANIZATION environment variable")
+		return diags
 	}
+	orgNamespace := findNamespaceFromID(orgID)
 
 	tflog.Info(ctx, "Token verified successfully", map[string]any{"org_id": orgID, "orgNamespace": orgNamespace})
 	tflog.Debug(ctx, "Creating ngpc client", map[string]any{"ngpcAPIServer": ngpcAPIServer})
-	cfg := ngpc.NewConfig(ngpcAPIServer, strRxtSpotToken, p.Version == "dev")
+	// The token is not baked into the config, instead the transport mints a new
+	// token from the token source before the current one expires.
+	cfg := ngpc.NewConfig(ngpcAPIServer, "", insecure)
+	transportSettings.apply(cfg)
+	cfg.WrapTransport = wrapTransportWithTokenSource(tokenSource)
 	ngpcClient, err := ngpc.CreateClientForConfig(cfg)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to create ngpc client", err.Error())
-		return
+		diags.AddError("Failed to create ngpc client", err.Error())
+		return diags
 	}
 	if ngpcClient == nil {
-		resp.Diagnostics.AddError("Failed to create ngpc client", "ngpcClient is nil")
-		return
+		diags.AddError("Failed to create ngpc client", "ngpcClient is nil")
+		return diags
 	}
-	// spotProviderData contains all dependencies needed by Resources and DataSources,
-	// including API clients and global provider state
-	spotProviderData := &SpotProviderData{
-		ngpcClient:      ngpcClient,
-		organizerClient: organizerClient,
+	// All API calls of the resources and data sources are retried on transient errors
+	ngpcClient = newRetryingClient(ngpcClient, retryPolicy)
+	d.ngpcClient = ngpcClient
+	d.organizerClient = organizerClient
+	d.tokenSource = tokenSource
+	d.transportSettings = transportSettings
+	d.orgID = orgID
+	d.namespace = orgNamespace
+	return diags
+}
+
+// ngpcTransportSettings holds the TLS and proxy settings applied to the ngpc client configs
+type ngpcTransportSettings struct {
+	// insecure is set by the user, the ngpc client configs of dev builds are insecure regardless
+	insecure bool
+	// caBundle is PEM encoded CA certificates used to verify the api server certificate
+	caBundle string
+	proxyURL *url.URL
+}
+
+func (s *ngpcTransportSettings) apply(cfg *rest.Config) {
+	if s.caBundle != "" {
+		cfg.TLSClientConfig.CAData = []byte(s.caBundle)
 	}
-	resp.ResourceData = spotProviderData
-	resp.DataSourceData = spotProviderData
+	if s.proxyURL != nil {
+		cfg.Proxy = http.ProxyURL(s.proxyURL)
+	}
+}
+
+// restConfig returns the config of a client of another API server, like the control plane of a cloudspace,
+// with the TLS and proxy settings of the provider.
+func (s *ngpcTransportSettings) restConfig(host string) *rest.Config {
+	cfg := &rest.Config{
+		Host:            host,
+		TLSClientConfig: rest.TLSClientConfig{Insecure: s.insecure},
+	}
+	s.apply(cfg)
+	return cfg
 }
 
 func (p *spotProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -247,21 +489,18 @@ func (p *spotProvider) Resources(ctx context.Context) []func() resource.Resource
 	}
 }
 
-func GetAccessToken(ctx context.Context, config *oauth2.Config, refreshToken string) (string, error) {
-	token := &oauth2.Token{
-		RefreshToken: refreshToken,
-	}
-
-	tokenSource := config.TokenSource(ctx, token)
+// GetAccessToken returns the id_token minted by the given token source along with
+// the refresh token, which differs from the original one if refresh token rotation is enabled
+func GetAccessToken(tokenSource oauth2.TokenSource) (string, string, error) {
 	newToken, err := tokenSource.Token()
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	accessToken, ok := newToken.Extra("id_token").(string)
 	if !ok {
-		return "", fmt.Errorf("id token not found")
+		return "", "", fmt.Errorf("id token not found")
 	}
 
-	return accessToken, nil
+	return accessToken, newToken.RefreshToken, nil
 }
@@ -4,7 +4,20 @@ package provider_spot
 
 import (
 	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/rackerlabs/terraform-provider-spot/internal/spotvalidator"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 )
@@ -12,16 +25,1032 @@ import (
 func SpotProviderSchema(ctx context.Context) schema.Schema {
 	return schema.Schema{
 		Attributes: map[string]schema.Attribute{
+			"api_server": schema.StringAttribute{
+				Optional:            true,
+				Description:         "URL of the Spot API server. Can also be set with the NGPC_APISERVER environment variable. Defaults to https://spot.rackspace.com.",
+				MarkdownDescription: "URL of the Spot API server. Can also be set with the NGPC_APISERVER environment variable. Defaults to https://spot.rackspace.com.",
+			},
+			"audience": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Audience requested with the client credentials grant. Can also be set with the RXTSPOT_AUDIENCE environment variable.",
+				MarkdownDescription: "Audience requested with the client credentials grant. Can also be set with the RXTSPOT_AUDIENCE environment variable.",
+			},
+			"budget": schema.SingleNestedAttribute{
+				Attributes: map[string]schema.Attribute{
+					"max_bid_price_per_server": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Maximum bid price in USD per server and hour of a spot node pool.",
+						MarkdownDescription: "Maximum bid price in USD per server and hour of a spot node pool.",
+						Validators: []validator.Float64{
+							float64validator.AtLeast(0.001),
+							spotvalidator.DecimalDigitsAtMost(3),
+						},
+					},
+					"max_hourly_spend": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Maximum estimated spend in USD per hour of all the node pools of the organization, see the estimated_hourly_cost of the node pools.",
+						MarkdownDescription: "Maximum estimated spend in USD per hour of all the node pools of the organization, see the estimated_hourly_cost of the node pools.",
+						Validators: []validator.Float64{
+							float64validator.AtLeast(0.001),
+						},
+					},
+				},
+				CustomType: BudgetType{
+					ObjectType: types.ObjectType{
+						AttrTypes: BudgetValue{}.AttributeTypes(ctx),
+					},
+				},
+				Optional:            true,
+				Description:         "Spend limits checked when planning spot and on-demand node pools. The plan fails with a breakdown of the spend when a node pool would exceed them. The node pools planned in the same run are checked together with the existing ones.",
+				MarkdownDescription: "Spend limits checked when planning spot and on-demand node pools. The plan fails with a breakdown of the spend when a node pool would exceed them. The node pools planned in the same run are checked together with the existing ones.",
+			},
+			"ca_bundle": schema.StringAttribute{
+				Optional:            true,
+				Description:         "PEM encoded CA certificates used to verify the certificates of the API server and of the control planes of cloudspaces. Can also be set with the RXTSPOT_CA_BUNDLE environment variable.",
+				MarkdownDescription: "PEM encoded CA certificates used to verify the certificates of the API server and of the control planes of cloudspaces. Can also be set with the RXTSPOT_CA_BUNDLE environment variable.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("insecure")),
+				},
+			},
+			"client_id": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Client ID of the machine to machine application used to authenticate against Spot backend using client credentials. Can also be set with the RXTSPOT_CLIENT_ID environment variable.",
+				MarkdownDescription: "Client ID of the machine to machine application used to authenticate against Spot backend using client credentials. Can also be set with the RXTSPOT_CLIENT_ID environment variable.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("token")),
+				},
+			},
+			"client_secret": schema.StringAttribute{
+				Optional:            true,
+				Sensitive:           true,
+				Description:         "Client secret of the machine to machine application. Can also be set with the RXTSPOT_CLIENT_SECRET environment variable.",
+				MarkdownDescription: "Client secret of the machine to machine application. Can also be set with the RXTSPOT_CLIENT_SECRET environment variable.",
+			},
+			"http_proxy": schema.StringAttribute{
+				Optional:            true,
+				Description:         "URL of the proxy used to reach the API server and the control planes of cloudspaces, for example http://proxy.example.com:3128. Can also be set with the RXTSPOT_HTTP_PROXY environment variable.",
+				MarkdownDescription: "URL of the proxy used to reach the API server and the control planes of cloudspaces, for example http://proxy.example.com:3128. Can also be set with the RXTSPOT_HTTP_PROXY environment variable.",
+			},
+			"insecure": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "If true, the certificates of the API server and of the control planes of cloudspaces are not verified. Can also be set with the RXTSPOT_INSECURE environment variable.",
+				MarkdownDescription: "If true, the certificates of the API server and of the control planes of cloudspaces are not verified. Can also be set with the RXTSPOT_INSECURE environment variable.",
+			},
+			"jwks_file": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Path of a local JWKS file with the keys used to verify the token, instead of fetching them from the issuer. Useful for runners that can not reach the issuer. Can also be set with the RXTSPOT_JWKS_FILE environment variable.",
+				MarkdownDescription: "Path of a local JWKS file with the keys used to verify the token, instead of fetching them from the issuer. Useful for runners that can not reach the issuer. Can also be set with the RXTSPOT_JWKS_FILE environment variable.",
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("public_keys_pem")),
+				},
+			},
+			"organization": schema.StringAttribute{
+				Optional:            true,
+				Description:         "ID or display name of the organization to operate in, for users belonging to several organizations. Required when the token does not carry the org_id claim, for example tokens issued to machine to machine applications, in which case it must be the ID. Can also be set with the RXTSPOT_ORGANIZATION environment variable.",
+				MarkdownDescription: "ID or display name of the organization to operate in, for users belonging to several organizations. Required when the token does not carry the org_id claim, for example tokens issued to machine to machine applications, in which case it must be the ID. Can also be set with the RXTSPOT_ORGANIZATION environment variable.",
+			},
+			"persist_rotated_refresh_token": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "If true, the rotated refresh token returned by the token endpoint is written back to the file set in RXTSPOT_TOKEN_FILE environment variable. Enable it when Auth0 refresh token rotation is enabled for the token.",
+				MarkdownDescription: "If true, the rotated refresh token returned by the token endpoint is written back to the file set in RXTSPOT_TOKEN_FILE environment variable. Enable it when Auth0 refresh token rotation is enabled for the token.",
+			},
+			"public_keys_pem": schema.StringAttribute{
+				Optional:            true,
+				Description:         "PEM encoded public keys or certificates used to verify the token, instead of fetching the keys from the issuer. Can also be set with the RXTSPOT_PUBLIC_KEYS_PEM environment variable.",
+				MarkdownDescription: "PEM encoded public keys or certificates used to verify the token, instead of fetching the keys from the issuer. Can also be set with the RXTSPOT_PUBLIC_KEYS_PEM environment variable.",
+			},
+			"retry": schema.SingleNestedAttribute{
+				Attributes: map[string]schema.Attribute{
+					"base_delay": schema.StringAttribute{
+						Optional:            true,
+						Description:         "Delay before the first retry, doubled on every subsequent retry, for example \"1s\". Defaults to 1s.",
+						MarkdownDescription: "Delay before the first retry, doubled on every subsequent retry, for example \"1s\". Defaults to 1s.",
+					},
+					"jitter": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Randomization factor between 0 and 1 applied to the delays. Defaults to 0.5.",
+						MarkdownDescription: "Randomization factor between 0 and 1 applied to the delays. Defaults to 0.5.",
+						Validators: []validator.Float64{
+							float64validator.Between(0, 1),
+						},
+					},
+					"max_attempts": schema.Int64Attribute{
+						Optional:            true,
+						Description:         "Maximum number of attempts of an API call, including the first one. Defaults to 5.",
+						MarkdownDescription: "Maximum number of attempts of an API call, including the first one. Defaults to 5.",
+						Validators: []validator.Int64{
+							int64validator.AtLeast(1),
+						},
+					},
+					"max_elapsed": schema.StringAttribute{
+						Optional:            true,
+						Description:         "Maximum total duration of the attempts of an API call, for example \"2m\". Defaults to 2m.",
+						MarkdownDescription: "Maximum total duration of the attempts of an API call, for example \"2m\". Defaults to 2m.",
+					},
+				},
+				CustomType: RetryType{
+					ObjectType: types.ObjectType{
+						AttrTypes: RetryValue{}.AttributeTypes(ctx),
+					},
+				},
+				Optional:            true,
+				Description:         "Retry policy of the Spot API calls failing with transient errors, such as throttling, server errors, connection resets and conflicts.",
+				MarkdownDescription: "Retry policy of the Spot API calls failing with transient errors, such as throttling, server errors, connection resets and conflicts.",
+			},
+			"skip_credentials_validation": schema.BoolAttribute{
+				Optional:            true,
+				Description:         "If true, the expiry, claims and signature of the token are not verified. The token is still required once a resource or data source of the provider is used.",
+				MarkdownDescription: "If true, the expiry, claims and signature of the token are not verified. The token is still required once a resource or data source of the provider is used.",
+			},
 			"token": schema.StringAttribute{
 				Optional:            true,
 				Sensitive:           true,
 				Description:         "API token used to authenticate against Spot backend",
 				MarkdownDescription: "API token used to authenticate against Spot backend",
 			},
+			"token_command": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				Description:         "Command to run to obtain the token, for example a wrapper around a secrets manager CLI. The first element is the executable and the rest are its arguments. The command must write a JSON document with access_token or refresh_token, and optionally expires_at in RFC3339 format, to stdout.",
+				MarkdownDescription: "Command to run to obtain the token, for example a wrapper around a secrets manager CLI. The first element is the executable and the rest are its arguments. The command must write a JSON document with access_token or refresh_token, and optionally expires_at in RFC3339 format, to stdout.",
+				Validators: []validator.List{
+					listvalidator.ConflictsWith(path.MatchRoot("token"), path.MatchRoot("client_id")),
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"token_command_timeout": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Maximum duration the token_command is allowed to run, for example \"30s\". Defaults to 30s.",
+				MarkdownDescription: "Maximum duration the token_command is allowed to run, for example \"30s\". Defaults to 30s.",
+			},
 		},
 	}
 }
 
 type SpotModel struct {
-	Token types.String `tfsdk:"token"`
+	ApiServer                  types.String `tfsdk:"api_server"`
+	Audience                   types.String `tfsdk:"audience"`
+	Budget                     BudgetValue  `tfsdk:"budget"`
+	CaBundle                   types.String `tfsdk:"ca_bundle"`
+	ClientId                   types.String `tfsdk:"client_id"`
+	ClientSecret               types.String `tfsdk:"client_secret"`
+	HttpProxy                  types.String `tfsdk:"http_proxy"`
+	Insecure                   types.Bool   `tfsdk:"insecure"`
+	JwksFile                   types.String `tfsdk:"jwks_file"`
+	Organization               types.String `tfsdk:"organization"`
+	PersistRotatedRefreshToken types.Bool   `tfsdk:"persist_rotated_refresh_token"`
+	PublicKeysPem              types.String `tfsdk:"public_keys_pem"`
+	Retry                      RetryValue   `tfsdk:"retry"`
+	SkipCredentialsValidation  types.Bool   `tfsdk:"skip_credentials_validation"`
+	Token                      types.String `tfsdk:"token"`
+	TokenCommand               types.List   `tfsdk:"token_command"`
+	TokenCommandTimeout        types.String `tfsdk:"token_command_timeout"`
+}
+
+var _ basetypes.ObjectTypable = BudgetType{}
+
+type BudgetType struct {
+	basetypes.ObjectType
+}
+
+func (t BudgetType) Equal(o attr.Type) bool {
+	other, ok := o.(BudgetType)
+
+	if !ok {
+		return false
+	}
+
+	return t.ObjectType.Equal(other.ObjectType)
+}
+
+func (t BudgetType) String() string {
+	return "BudgetType"
+}
+
+func (t BudgetType) ValueFromObject(ctx context.Context, in basetypes.ObjectValue) (basetypes.ObjectValuable, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	attributes := in.Attributes()
+
+	maxBidPricePerServerAttribute, ok := attributes["max_bid_price_per_server"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_bid_price_per_server is missing from object`)
+
+		return nil, diags
+	}
+
+	maxBidPricePerServerVal, ok := maxBidPricePerServerAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_bid_price_per_server expected to be basetypes.Float64Value, was: %T`, maxBidPricePerServerAttribute))
+	}
+
+	maxHourlySpendAttribute, ok := attributes["max_hourly_spend"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_hourly_spend is missing from object`)
+
+		return nil, diags
+	}
+
+	maxHourlySpendVal, ok := maxHourlySpendAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_hourly_spend expected to be basetypes.Float64Value, was: %T`, maxHourlySpendAttribute))
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return BudgetValue{
+		MaxBidPricePerServer: maxBidPricePerServerVal,
+		MaxHourlySpend:       maxHourlySpendVal,
+		state:                attr.ValueStateKnown,
+	}, diags
+}
+
+func NewBudgetValueNull() BudgetValue {
+	return BudgetValue{
+		state: attr.ValueStateNull,
+	}
+}
+
+func NewBudgetValueUnknown() BudgetValue {
+	return BudgetValue{
+		state: attr.ValueStateUnknown,
+	}
+}
+
+func NewBudgetValue(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) (BudgetValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// Reference: https://github.com/hashicorp/terraform-plugin-framework/issues/521
+	ctx := context.Background()
+
+	for name, attributeType := range attributeTypes {
+		attribute, ok := attributes[name]
+
+		if !ok {
+			diags.AddError(
+				"Missing BudgetValue Attribute Value",
+				"While creating a BudgetValue value, a missing attribute value was detected. "+
+					"A BudgetValue must contain values for all attributes, even if null or unknown. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("BudgetValue Attribute Name (%s) Expected Type: %s", name, attributeType.String()),
+			)
+
+			continue
+		}
+
+		if !attributeType.Equal(attribute.Type(ctx)) {
+			diags.AddError(
+				"Invalid BudgetValue Attribute Type",
+				"While creating a BudgetValue value, an invalid attribute value was detected. "+
+					"A BudgetValue must use a matching attribute type for the value. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("BudgetValue Attribute Name (%s) Expected Type: %s\n", name, attributeType.String())+
+					fmt.Sprintf("BudgetValue Attribute Name (%s) Given Type: %s", name, attribute.Type(ctx)),
+			)
+		}
+	}
+
+	for name := range attributes {
+		_, ok := attributeTypes[name]
+
+		if !ok {
+			diags.AddError(
+				"Extra BudgetValue Attribute Value",
+				"While creating a BudgetValue value, an extra attribute value was detected. "+
+					"A BudgetValue must not contain values beyond the expected attribute types. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("Extra BudgetValue Attribute Name: %s", name),
+			)
+		}
+	}
+
+	if diags.HasError() {
+		return NewBudgetValueUnknown(), diags
+	}
+
+	maxBidPricePerServerAttribute, ok := attributes["max_bid_price_per_server"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_bid_price_per_server is missing from object`)
+
+		return NewBudgetValueUnknown(), diags
+	}
+
+	maxBidPricePerServerVal, ok := maxBidPricePerServerAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_bid_price_per_server expected to be basetypes.Float64Value, was: %T`, maxBidPricePerServerAttribute))
+	}
+
+	maxHourlySpendAttribute, ok := attributes["max_hourly_spend"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_hourly_spend is missing from object`)
+
+		return NewBudgetValueUnknown(), diags
+	}
+
+	maxHourlySpendVal, ok := maxHourlySpendAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_hourly_spend expected to be basetypes.Float64Value, was: %T`, maxHourlySpendAttribute))
+	}
+
+	if diags.HasError() {
+		return NewBudgetValueUnknown(), diags
+	}
+
+	return BudgetValue{
+		MaxBidPricePerServer: maxBidPricePerServerVal,
+		MaxHourlySpend:       maxHourlySpendVal,
+		state:                attr.ValueStateKnown,
+	}, diags
+}
+
+func NewBudgetValueMust(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) BudgetValue {
+	object, diags := NewBudgetValue(attributeTypes, attributes)
+
+	if diags.HasError() {
+		// This could potentially be added to the diag package.
+		diagsStrings := make([]string, 0, len(diags))
+
+		for _, diagnostic := range diags {
+			diagsStrings = append(diagsStrings, fmt.Sprintf(
+				"%s | %s | %s",
+				diagnostic.Severity(),
+				diagnostic.Summary(),
+				diagnostic.Detail()))
+		}
+
+		panic("NewBudgetValueMust received error(s): " + strings.Join(diagsStrings, "\n"))
+	}
+
+	return object
+}
+
+func (t BudgetType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	if in.Type() == nil {
+		return NewBudgetValueNull(), nil
+	}
+
+	if !in.Type().Equal(t.TerraformType(ctx)) {
+		return nil, fmt.Errorf("expected %s, got %s", t.TerraformType(ctx), in.Type())
+	}
+
+	if !in.IsKnown() {
+		return NewBudgetValueUnknown(), nil
+	}
+
+	if in.IsNull() {
+		return NewBudgetValueNull(), nil
+	}
+
+	attributes := map[string]attr.Value{}
+
+	val := map[string]tftypes.Value{}
+
+	err := in.As(&val)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range val {
+		a, err := t.AttrTypes[k].ValueFromTerraform(ctx, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attributes[k] = a
+	}
+
+	return NewBudgetValueMust(BudgetValue{}.AttributeTypes(ctx), attributes), nil
+}
+
+func (t BudgetType) ValueType(ctx context.Context) attr.Value {
+	return BudgetValue{}
+}
+
+var _ basetypes.ObjectValuable = BudgetValue{}
+
+type BudgetValue struct {
+	MaxBidPricePerServer basetypes.Float64Value `tfsdk:"max_bid_price_per_server"`
+	MaxHourlySpend       basetypes.Float64Value `tfsdk:"max_hourly_spend"`
+	state                attr.ValueState
+}
+
+func (v BudgetValue) ToTerraformValue(ctx context.Context) (tftypes.Value, error) {
+	attrTypes := make(map[string]tftypes.Type, 2)
+
+	var val tftypes.Value
+	var err error
+
+	attrTypes["max_bid_price_per_server"] = basetypes.Float64Type{}.TerraformType(ctx)
+	attrTypes["max_hourly_spend"] = basetypes.Float64Type{}.TerraformType(ctx)
+
+	objectType := tftypes.Object{AttributeTypes: attrTypes}
+
+	switch v.state {
+	case attr.ValueStateKnown:
+		vals := make(map[string]tftypes.Value, 2)
+
+		val, err = v.MaxBidPricePerServer.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["max_bid_price_per_server"] = val
+
+		val, err = v.MaxHourlySpend.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["max_hourly_spend"] = val
+
+		if err := tftypes.ValidateValue(objectType, vals); err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		return tftypes.NewValue(objectType, vals), nil
+	case attr.ValueStateNull:
+		return tftypes.NewValue(objectType, nil), nil
+	case attr.ValueStateUnknown:
+		return tftypes.NewValue(objectType, tftypes.UnknownValue), nil
+	default:
+		panic(fmt.Sprintf("unhandled Object state in ToTerraformValue: %s", v.state))
+	}
+}
+
+func (v BudgetValue) IsNull() bool {
+	return v.state == attr.ValueStateNull
+}
+
+func (v BudgetValue) IsUnknown() bool {
+	return v.state == attr.ValueStateUnknown
+}
+
+func (v BudgetValue) String() string {
+	return "BudgetValue"
+}
+
+func (v BudgetValue) ToObjectValue(ctx context.Context) (basetypes.ObjectValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	objVal, diags := types.ObjectValue(
+		map[string]attr.Type{
+			"max_bid_price_per_server": basetypes.Float64Type{},
+			"max_hourly_spend":         basetypes.Float64Type{},
+		},
+		map[string]attr.Value{
+			"max_bid_price_per_server": v.MaxBidPricePerServer,
+			"max_hourly_spend":         v.MaxHourlySpend,
+		})
+
+	return objVal, diags
+}
+
+func (v BudgetValue) Equal(o attr.Value) bool {
+	other, ok := o.(BudgetValue)
+
+	if !ok {
+		return false
+	}
+
+	if v.state != other.state {
+		return false
+	}
+
+	if v.state != attr.ValueStateKnown {
+		return true
+	}
+
+	if !v.MaxBidPricePerServer.Equal(other.MaxBidPricePerServer) {
+		return false
+	}
+
+	if !v.MaxHourlySpend.Equal(other.MaxHourlySpend) {
+		return false
+	}
+
+	return true
+}
+
+func (v BudgetValue) Type(ctx context.Context) attr.Type {
+	return BudgetType{
+		basetypes.ObjectType{
+			AttrTypes: v.AttributeTypes(ctx),
+		},
+	}
+}
+
+func (v BudgetValue) AttributeTypes(ctx context.Context) map[string]attr.Type {
+	return map[string]attr.Type{
+		"max_bid_price_per_server": basetypes.Float64Type{},
+		"max_hourly_spend":         basetypes.Float64Type{},
+	}
+}
+
+var _ basetypes.ObjectTypable = RetryType{}
+
+type RetryType struct {
+	basetypes.ObjectType
+}
+
+func (t RetryType) Equal(o attr.Type) bool {
+	other, ok := o.(RetryType)
+
+	if !ok {
+		return false
+	}
+
+	return t.ObjectType.Equal(other.ObjectType)
+}
+
+func (t RetryType) String() string {
+	return "RetryType"
+}
+
+func (t RetryType) ValueFromObject(ctx context.Context, in basetypes.ObjectValue) (basetypes.ObjectValuable, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	attributes := in.Attributes()
+
+	baseDelayAttribute, ok := attributes["base_delay"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`base_delay is missing from object`)
+
+		return nil, diags
+	}
+
+	baseDelayVal, ok := baseDelayAttribute.(basetypes.StringValue)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`base_delay expected to be basetypes.StringValue, was: %T`, baseDelayAttribute))
+	}
+
+	jitterAttribute, ok := attributes["jitter"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`jitter is missing from object`)
+
+		return nil, diags
+	}
+
+	jitterVal, ok := jitterAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`jitter expected to be basetypes.Float64Value, was: %T`, jitterAttribute))
+	}
+
+	maxAttemptsAttribute, ok := attributes["max_attempts"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_attempts is missing from object`)
+
+		return nil, diags
+	}
+
+	maxAttemptsVal, ok := maxAttemptsAttribute.(basetypes.Int64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_attempts expected to be basetypes.Int64Value, was: %T`, maxAttemptsAttribute))
+	}
+
+	maxElapsedAttribute, ok := attributes["max_elapsed"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_elapsed is missing from object`)
+
+		return nil, diags
+	}
+
+	maxElapsedVal, ok := maxElapsedAttribute.(basetypes.StringValue)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_elapsed expected to be basetypes.StringValue, was: %T`, maxElapsedAttribute))
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return RetryValue{
+		BaseDelay:   baseDelayVal,
+		Jitter:      jitterVal,
+		MaxAttempts: maxAttemptsVal,
+		MaxElapsed:  maxElapsedVal,
+		state:       attr.ValueStateKnown,
+	}, diags
+}
+
+func NewRetryValueNull() RetryValue {
+	return RetryValue{
+		state: attr.ValueStateNull,
+	}
+}
+
+func NewRetryValueUnknown() RetryValue {
+	return RetryValue{
+		state: attr.ValueStateUnknown,
+	}
+}
+
+func NewRetryValue(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) (RetryValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// Reference: https://github.com/hashicorp/terraform-plugin-framework/issues/521
+	ctx := context.Background()
+
+	for name, attributeType := range attributeTypes {
+		attribute, ok := attributes[name]
+
+		if !ok {
+			diags.AddError(
+				"Missing RetryValue Attribute Value",
+				"While creating a RetryValue value, a missing attribute value was detected. "+
+					"A RetryValue must contain values for all attributes, even if null or unknown. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("RetryValue Attribute Name (%s) Expected Type: %s", name, attributeType.String()),
+			)
+
+			continue
+		}
+
+		if !attributeType.Equal(attribute.Type(ctx)) {
+			diags.AddError(
+				"Invalid RetryValue Attribute Type",
+				"While creating a RetryValue value, an invalid attribute value was detected. "+
+					"A RetryValue must use a matching attribute type for the value. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("RetryValue Attribute Name (%s) Expected Type: %s\n", name, attributeType.String())+
+					fmt.Sprintf("RetryValue Attribute Name (%s) Given Type: %s", name, attribute.Type(ctx)),
+			)
+		}
+	}
+
+	for name := range attributes {
+		_, ok := attributeTypes[name]
+
+		if !ok {
+			diags.AddError(
+				"Extra RetryValue Attribute Value",
+				"While creating a RetryValue value, an extra attribute value was detected. "+
+					"A RetryValue must not contain values beyond the expected attribute types. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("Extra RetryValue Attribute Name: %s", name),
+			)
+		}
+	}
+
+	if diags.HasError() {
+		return NewRetryValueUnknown(), diags
+	}
+
+	baseDelayAttribute, ok := attributes["base_delay"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`base_delay is missing from object`)
+
+		return NewRetryValueUnknown(), diags
+	}
+
+	baseDelayVal, ok := baseDelayAttribute.(basetypes.StringValue)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`base_delay expected to be basetypes.StringValue, was: %T`, baseDelayAttribute))
+	}
+
+	jitterAttribute, ok := attributes["jitter"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`jitter is missing from object`)
+
+		return NewRetryValueUnknown(), diags
+	}
+
+	jitterVal, ok := jitterAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`jitter expected to be basetypes.Float64Value, was: %T`, jitterAttribute))
+	}
+
+	maxAttemptsAttribute, ok := attributes["max_attempts"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_attempts is missing from object`)
+
+		return NewRetryValueUnknown(), diags
+	}
+
+	maxAttemptsVal, ok := maxAttemptsAttribute.(basetypes.Int64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_attempts expected to be basetypes.Int64Value, was: %T`, maxAttemptsAttribute))
+	}
+
+	maxElapsedAttribute, ok := attributes["max_elapsed"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_elapsed is missing from object`)
+
+		return NewRetryValueUnknown(), diags
+	}
+
+	maxElapsedVal, ok := maxElapsedAttribute.(basetypes.StringValue)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_elapsed expected to be basetypes.StringValue, was: %T`, maxElapsedAttribute))
+	}
+
+	if diags.HasError() {
+		return NewRetryValueUnknown(), diags
+	}
+
+	return RetryValue{
+		BaseDelay:   baseDelayVal,
+		Jitter:      jitterVal,
+		MaxAttempts: maxAttemptsVal,
+		MaxElapsed:  maxElapsedVal,
+		state:       attr.ValueStateKnown,
+	}, diags
+}
+
+func NewRetryValueMust(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) RetryValue {
+	object, diags := NewRetryValue(attributeTypes, attributes)
+
+	if diags.HasError() {
+		// This could potentially be added to the diag package.
+		diagsStrings := make([]string, 0, len(diags))
+
+		for _, diagnostic := range diags {
+			diagsStrings = append(diagsStrings, fmt.Sprintf(
+				"%s | %s | %s",
+				diagnostic.Severity(),
+				diagnostic.Summary(),
+				diagnostic.Detail()))
+		}
+
+		panic("NewRetryValueMust received error(s): " + strings.Join(diagsStrings, "\n"))
+	}
+
+	return object
+}
+
+func (t RetryType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	if in.Type() == nil {
+		return NewRetryValueNull(), nil
+	}
+
+	if !in.Type().Equal(t.TerraformType(ctx)) {
+		return nil, fmt.Errorf("expected %s, got %s", t.TerraformType(ctx), in.Type())
+	}
+
+	if !in.IsKnown() {
+		return NewRetryValueUnknown(), nil
+	}
+
+	if in.IsNull() {
+		return NewRetryValueNull(), nil
+	}
+
+	attributes := map[string]attr.Value{}
+
+	val := map[string]tftypes.Value{}
+
+	err := in.As(&val)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range val {
+		a, err := t.AttrTypes[k].ValueFromTerraform(ctx, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attributes[k] = a
+	}
+
+	return NewRetryValueMust(RetryValue{}.AttributeTypes(ctx), attributes), nil
+}
+
+func (t RetryType) ValueType(ctx context.Context) attr.Value {
+	return RetryValue{}
+}
+
+var _ basetypes.ObjectValuable = RetryValue{}
+
+type RetryValue struct {
+	BaseDelay   basetypes.StringValue  `tfsdk:"base_delay"`
+	Jitter      basetypes.Float64Value `tfsdk:"jitter"`
+	MaxAttempts basetypes.Int64Value   `tfsdk:"max_attempts"`
+	MaxElapsed  basetypes.StringValue  `tfsdk:"max_elapsed"`
+	state       attr.ValueState
+}
+
+func (v RetryValue) ToTerraformValue(ctx context.Context) (tftypes.Value, error) {
+	attrTypes := make(map[string]tftypes.Type, 4)
+
+	var val tftypes.Value
+	var err error
+
+	attrTypes["base_delay"] = basetypes.StringType{}.TerraformType(ctx)
+	attrTypes["jitter"] = basetypes.Float64Type{}.TerraformType(ctx)
+	attrTypes["max_attempts"] = basetypes.Int64Type{}.TerraformType(ctx)
+	attrTypes["max_elapsed"] = basetypes.StringType{}.TerraformType(ctx)
+
+	objectType := tftypes.Object{AttributeTypes: attrTypes}
+
+	switch v.state {
+	case attr.ValueStateKnown:
+		vals := make(map[string]tftypes.Value, 4)
+
+		val, err = v.BaseDelay.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["base_delay"] = val
+
+		val, err = v.Jitter.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["jitter"] = val
+
+		val, err = v.MaxAttempts.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["max_attempts"] = val
+
+		val, err = v.MaxElapsed.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["max_elapsed"] = val
+
+		if err := tftypes.ValidateValue(objectType, vals); err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		return tftypes.NewValue(objectType, vals), nil
+	case attr.ValueStateNull:
+		return tftypes.NewValue(objectType, nil), nil
+	case attr.ValueStateUnknown:
+		return tftypes.NewValue(objectType, tftypes.UnknownValue), nil
+	default:
+		panic(fmt.Sprintf("unhandled Object state in ToTerraformValue: %s", v.state))
+	}
+}
+
+func (v RetryValue) IsNull() bool {
+	return v.state == attr.ValueStateNull
+}
+
+func (v RetryValue) IsUnknown() bool {
+	return v.state == attr.ValueStateUnknown
+}
+
+func (v RetryValue) String() string {
+	return "RetryValue"
+}
+
+func (v RetryValue) ToObjectValue(ctx context.Context) (basetypes.ObjectValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	objVal, diags := types.ObjectValue(
+		map[string]attr.Type{
+			"base_delay":   basetypes.StringType{},
+			"jitter":       basetypes.Float64Type{},
+			"max_attempts": basetypes.Int64Type{},
+			"max_elapsed":  basetypes.StringType{},
+		},
+		map[string]attr.Value{
+			"base_delay":   v.BaseDelay,
+			"jitter":       v.Jitter,
+			"max_attempts": v.MaxAttempts,
+			"max_elapsed":  v.MaxElapsed,
+		})
+
+	return objVal, diags
+}
+
+func (v RetryValue) Equal(o attr.Value) bool {
+	other, ok := o.(RetryValue)
+
+	if !ok {
+		return false
+	}
+
+	if v.state != other.state {
+		return false
+	}
+
+	if v.state != attr.ValueStateKnown {
+		return true
+	}
+
+	if !v.BaseDelay.Equal(other.BaseDelay) {
+		return false
+	}
+
+	if !v.Jitter.Equal(other.Jitter) {
+		return false
+	}
+
+	if !v.MaxAttempts.Equal(other.MaxAttempts) {
+		return false
+	}
+
+	if !v.MaxElapsed.Equal(other.MaxElapsed) {
+		return false
+	}
+
+	return true
+}
+
+func (v RetryValue) Type(ctx context.Context) attr.Type {
+	return RetryType{
+		basetypes.ObjectType{
+			AttrTypes: v.AttributeTypes(ctx),
+		},
+	}
+}
+
+func (v RetryValue) AttributeTypes(ctx context.Context) map[string]attr.Type {
+	return map[string]attr.Type{
+		"base_delay":   basetypes.StringType{},
+		"jitter":       basetypes.Float64Type{},
+		"max_attempts": basetypes.Int64Type{},
+		"max_elapsed":  basetypes.StringType{},
+	}
 }
@@ -23,6 +23,7 @@ func NewRegionDataSource() datasource.DataSource {
 
 type regionDataSource struct {
 	ngpcClient ngpc.Client
+	namespace  string
 }
 
 func (d *regionDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -47,10 +48,20 @@ func (d *regionDataSource) Configure(ctx context.Context, req datasource.Configu
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
+	d.namespace = spotProviderData.namespace
 }
 
 func (d *regionDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_region.RegionModel
 
 	// Read Terraform configuration data into the model
@@ -62,13 +73,9 @@ func (d *regionDataSource) Read(ctx context.Context, req datasource.ReadRequest,
 
 	// Read API call logic
 	name := data.Name.ValueString()
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := d.namespace
 	region := &ngpcv1.Region{}
-	err = d.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, region)
+	err := d.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, region)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get region", err.Error())
 		return
@@ -47,10 +47,19 @@ func (d *regionsDataSource) Configure(ctx context.Context, req datasource.Config
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
 }
 
 func (d *regionsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_regions.RegionsModel
 
 	// Read Terraform configuration data into the model
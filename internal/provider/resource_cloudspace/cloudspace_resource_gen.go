@@ -11,6 +11,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/float64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
@@ -31,6 +32,14 @@ import (
 func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 	return schema.Schema{
 		Attributes: map[string]schema.Attribute{
+			"api_server_endpoint": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Kubernetes api server URL",
+				MarkdownDescription: "Kubernetes api server URL",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
 			"bids": schema.SetNestedAttribute{
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
@@ -85,6 +94,13 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 				},
 				Default: stringdefault.StaticString("calico"),
 			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, the cloudspace can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the cloudspace.",
+				MarkdownDescription: "If true, the cloudspace can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the cloudspace.",
+				Default:             booldefault.StaticBool(false),
+			},
 			"deployment_type": schema.StringAttribute{
 				Optional:            true,
 				Computed:            true,
@@ -100,6 +116,22 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 				},
 				Default: stringdefault.StaticString("gen2"),
 			},
+			"estimated_hourly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pools of the cloudspace in USD per hour, the sum of their estimated_hourly_cost. It is updated on refresh, node pools changed in the same apply are accounted for on the next one.",
+				MarkdownDescription: "Estimated cost of the node pools of the cloudspace in USD per hour, the sum of their estimated_hourly_cost. It is updated on refresh, node pools changed in the same apply are accounted for on the next one.",
+				PlanModifiers: []planmodifier.Float64{
+					float64planmodifier.UseStateForUnknown(),
+				},
+			},
+			"estimated_monthly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pools of the cloudspace in USD per month of 730 hours, see estimated_hourly_cost.",
+				MarkdownDescription: "Estimated cost of the node pools of the cloudspace in USD per month of 730 hours, see estimated_hourly_cost.",
+				PlanModifiers: []planmodifier.Float64{
+					float64planmodifier.UseStateForUnknown(),
+				},
+			},
 			"first_ready_timestamp": schema.StringAttribute{
 				Computed:            true,
 				Description:         "The time when the cloudspace was first ready.",
@@ -118,6 +150,11 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 				},
 				Default: booldefault.StaticBool(false),
 			},
+			"health": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Health indicates if CloudSpace has a working APIServer and available nodes",
+				MarkdownDescription: "Health indicates if CloudSpace has a working APIServer and available nodes",
+			},
 			"id": schema.StringAttribute{
 				Computed:            true,
 				Description:         "The id of the cloudspace",
@@ -130,8 +167,8 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 			"kubernetes_version": schema.StringAttribute{
 				Optional:            true,
 				Computed:            true,
-				Description:         "Kubernetes version to deploy in the cloudspace. Supported values: 1.29.6, 1.30.10, 1.31.1",
-				MarkdownDescription: "Kubernetes version to deploy in the cloudspace. Supported values: 1.29.6, 1.30.10, 1.31.1",
+				Description:         "Kubernetes version to deploy in the cloudspace. Supported values: 1.29.6, 1.30.10, 1.31.1. Changing it upgrades the cloudspace in place, one minor version at a time; downgrades are not supported. With wait_until_ready, the update waits until the API server of the control plane reports the new version.",
+				MarkdownDescription: "Kubernetes version to deploy in the cloudspace. Supported values: 1.29.6, 1.30.10, 1.31.1. Changing it upgrades the cloudspace in place, one minor version at a time; downgrades are not supported. With wait_until_ready, the update waits until the API server of the control plane reports the new version.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
@@ -160,6 +197,14 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 					stringvalidator.AtLeastOneOf(path.Expressions{path.MatchRoot("name"), path.MatchRoot("cloudspace_name")}...),
 				},
 			},
+			"on_delete_nodepools": schema.StringAttribute{
+				Optional:            true,
+				Description:         "What to do with the node pools still listed in the cloudspace when it is deleted. \"block\" fails the deletion with the list of remaining node pools, \"cascade\" deletes them and waits for them to be gone before deleting the cloudspace, both within the delete timeout. When not set, the cloudspace is deleted without checking its node pools. Supported values: block, cascade",
+				MarkdownDescription: "What to do with the node pools still listed in the cloudspace when it is deleted. \"block\" fails the deletion with the list of remaining node pools, \"cascade\" deletes them and waits for them to be gone before deleting the cloudspace, both within the delete timeout. When not set, the cloudspace is deleted without checking its node pools. Supported values: block, cascade",
+				Validators: []validator.String{
+					stringvalidator.OneOf("block", "cascade"),
+				},
+			},
 			"pending_allocations": schema.SetNestedAttribute{
 				NestedObject: schema.NestedAttributeObject{
 					Attributes: map[string]schema.Attribute{
@@ -181,6 +226,11 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 				},
 				Computed: true,
 			},
+			"phase": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Phase of the cloudspace",
+				MarkdownDescription: "Phase of the cloudspace",
+			},
 			"preemption_webhook": schema.StringAttribute{
 				Optional:            true,
 				Description:         "Webhook URL for preemption notifications.",
@@ -190,6 +240,11 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 					stringvalidator.RegexMatches(regexp.MustCompile(`^http(s)?://.+`), "Must be a valid URL"),
 				},
 			},
+			"reason": schema.StringAttribute{
+				Computed:            true,
+				Description:         "Reason contains the reason why the CloudSpace is in a certain phase.",
+				MarkdownDescription: "Reason contains the reason why the CloudSpace is in a certain phase.",
+			},
 			"region": schema.StringAttribute{
 				Required:            true,
 				Description:         "The region where the cloudspace will be created.",
@@ -209,7 +264,16 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 			},
 			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
 				Create: true,
+				Update: true,
+				Delete: true,
 			}),
+			"wait_for_nodes": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, waits until the node pools of the cloudspace have won their desired or minimum number of servers, within the create or update timeout. Pending allocations are reported on timeout.",
+				MarkdownDescription: "If true, waits until the node pools of the cloudspace have won their desired or minimum number of servers, within the create or update timeout. Pending allocations are reported on timeout.",
+				Default:             booldefault.StaticBool(false),
+			},
 			"wait_until_ready": schema.BoolAttribute{
 				Optional:            true,
 				Computed:            true,
@@ -225,22 +289,31 @@ func CloudspaceResourceSchema(ctx context.Context) schema.Schema {
 }
 
 type CloudspaceModel struct {
-	Bids                types.Set    `tfsdk:"bids"`
-	CloudspaceName      types.String `tfsdk:"cloudspace_name"`
-	Cni                 types.String `tfsdk:"cni"`
-	DeploymentType      types.String `tfsdk:"deployment_type"`
-	FirstReadyTimestamp types.String `tfsdk:"first_ready_timestamp"`
-	HacontrolPlane      types.Bool   `tfsdk:"hacontrol_plane"`
-	Id                  types.String `tfsdk:"id"`
-	KubernetesVersion   types.String `tfsdk:"kubernetes_version"`
-	LastUpdated         types.String `tfsdk:"last_updated"`
-	Name                types.String `tfsdk:"name"`
-	PendingAllocations  types.Set    `tfsdk:"pending_allocations"`
-	PreemptionWebhook   types.String `tfsdk:"preemption_webhook"`
-	Region              types.String `tfsdk:"region"`
-	SpotnodepoolIds     types.List   `tfsdk:"spotnodepool_ids"`
+	ApiServerEndpoint    types.String  `tfsdk:"api_server_endpoint"`
+	Bids                 types.Set     `tfsdk:"bids"`
+	CloudspaceName       types.String  `tfsdk:"cloudspace_name"`
+	Cni                  types.String  `tfsdk:"cni"`
+	DeletionProtection   types.Bool    `tfsdk:"deletion_protection"`
+	DeploymentType       types.String  `tfsdk:"deployment_type"`
+	EstimatedHourlyCost  types.Float64 `tfsdk:"estimated_hourly_cost"`
+	EstimatedMonthlyCost types.Float64 `tfsdk:"estimated_monthly_cost"`
+	FirstReadyTimestamp  types.String  `tfsdk:"first_ready_timestamp"`
+	HacontrolPlane       types.Bool    `tfsdk:"hacontrol_plane"`
+	Health               types.String  `tfsdk:"health"`
+	Id                   types.String  `tfsdk:"id"`
+	KubernetesVersion    types.String  `tfsdk:"kubernetes_version"`
+	LastUpdated          types.String  `tfsdk:"last_updated"`
+	Name                 types.String  `tfsdk:"name"`
+	OnDeleteNodepools    types.String  `tfsdk:"on_delete_nodepools"`
+	PendingAllocations   types.Set     `tfsdk:"pending_allocations"`
+	Phase                types.String  `tfsdk:"phase"`
+	PreemptionWebhook    types.String  `tfsdk:"preemption_webhook"`
+	Reason               types.String  `tfsdk:"reason"`
+	Region               types.String  `tfsdk:"region"`
+	SpotnodepoolIds      types.List    `tfsdk:"spotnodepool_ids"`
 	Timeouts timeouts.Value `tfsdk:"timeouts"`
-	WaitUntilReady      types.Bool   `tfsdk:"wait_until_ready"`
+	WaitForNodes         types.Bool    `tfsdk:"wait_for_nodes"`
+	WaitUntilReady       types.Bool    `tfsdk:"wait_until_ready"`
 }
 
 var _ basetypes.ObjectTypable = BidsType{}
@@ -5,10 +5,13 @@ package resource_ondemandnodepool
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -43,6 +46,13 @@ func OndemandnodepoolResourceSchema(ctx context.Context) schema.Schema {
 					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?$`), "Must be valid kubernetes name"),
 				},
 			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, the on-demand node pool can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the node pool.",
+				MarkdownDescription: "If true, the on-demand node pool can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the node pool.",
+				Default:             booldefault.StaticBool(false),
+			},
 			"desired_server_count": schema.Int64Attribute{
 				Required:            true,
 				Description:         "The desired number of servers in the node pool.",
@@ -51,6 +61,16 @@ func OndemandnodepoolResourceSchema(ctx context.Context) schema.Schema {
 					int64validator.AtLeast(1),
 				},
 			},
+			"estimated_hourly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pool in USD per hour, the on-demand price of the server class times the desired number of servers.",
+				MarkdownDescription: "Estimated cost of the node pool in USD per hour, the on-demand price of the server class times the desired number of servers.",
+			},
+			"estimated_monthly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pool in USD per month of 730 hours, see estimated_hourly_cost.",
+				MarkdownDescription: "Estimated cost of the node pool in USD per month of 730 hours, see estimated_hourly_cost.",
+			},
 			"labels": schema.MapAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
@@ -66,11 +86,30 @@ func OndemandnodepoolResourceSchema(ctx context.Context) schema.Schema {
 				},
 			},
 			"name": schema.StringAttribute{
+				Optional:            true,
 				Computed:            true,
-				Description:         "The name of the ondemandnodepool.",
-				MarkdownDescription: "The name of the ondemandnodepool.",
+				Description:         "The name of the ondemandnodepool. It must consist of lowercase alphanumeric characters or '-', and changing it replaces the node pool. A random UUID is used if neither name nor name_prefix is set.",
+				MarkdownDescription: "The name of the ondemandnodepool. It must consist of lowercase alphanumeric characters or '-', and changing it replaces the node pool. A random UUID is used if neither name nor name_prefix is set.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 63),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`), "Must be a lowercase RFC 1123 label"),
+					stringvalidator.ConflictsWith(path.MatchRoot("name_prefix")),
+				},
+			},
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Creates a unique name beginning with the given prefix, followed by 8 random characters. Conflicts with name, changing it replaces the node pool.",
+				MarkdownDescription: "Creates a unique name beginning with the given prefix, followed by 8 random characters. Conflicts with name, changing it replaces the node pool.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 55),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-z0-9][-a-z0-9]*$`), "Must start with a lowercase alphanumeric character followed by lowercase alphanumeric characters or '-'"),
 				},
 			},
 			"reserved_count": schema.Int64Attribute{
@@ -129,21 +168,38 @@ func OndemandnodepoolResourceSchema(ctx context.Context) schema.Schema {
 				Description:         "Kubernetes taints to be applied to the nodes of the node pool",
 				MarkdownDescription: "Kubernetes taints to be applied to the nodes of the node pool",
 			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"wait_for_nodes": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, waits until the desired number of servers of the node pool are reserved, within the create or update timeout.",
+				MarkdownDescription: "If true, waits until the desired number of servers of the node pool are reserved, within the create or update timeout.",
+				Default:             booldefault.StaticBool(false),
+			},
 		},
 	}
 }
 
 type OndemandnodepoolModel struct {
-	Annotations        types.Map    `tfsdk:"annotations"`
-	CloudspaceName     types.String `tfsdk:"cloudspace_name"`
-	DesiredServerCount types.Int64  `tfsdk:"desired_server_count"`
-	Labels             types.Map    `tfsdk:"labels"`
-	LastUpdated        types.String `tfsdk:"last_updated"`
-	Name               types.String `tfsdk:"name"`
-	ReservedCount      types.Int64  `tfsdk:"reserved_count"`
-	ReservedStatus     types.String `tfsdk:"reserved_status"`
-	ServerClass        types.String `tfsdk:"server_class"`
-	Taints             types.List   `tfsdk:"taints"`
+	Annotations          types.Map      `tfsdk:"annotations"`
+	CloudspaceName       types.String   `tfsdk:"cloudspace_name"`
+	DeletionProtection   types.Bool     `tfsdk:"deletion_protection"`
+	DesiredServerCount   types.Int64    `tfsdk:"desired_server_count"`
+	EstimatedHourlyCost  types.Float64  `tfsdk:"estimated_hourly_cost"`
+	EstimatedMonthlyCost types.Float64  `tfsdk:"estimated_monthly_cost"`
+	Labels               types.Map      `tfsdk:"labels"`
+	LastUpdated          types.String   `tfsdk:"last_updated"`
+	Name                 types.String   `tfsdk:"name"`
+	NamePrefix           types.String   `tfsdk:"name_prefix"`
+	ReservedCount        types.Int64    `tfsdk:"reserved_count"`
+	ReservedStatus       types.String   `tfsdk:"reserved_status"`
+	ServerClass          types.String   `tfsdk:"server_class"`
+	Taints               types.List     `tfsdk:"taints"`
+	Timeouts             timeouts.Value `tfsdk:"timeouts"`
+	WaitForNodes         types.Bool     `tfsdk:"wait_for_nodes"`
 }
 
 var _ basetypes.ObjectTypable = TaintsType{}
@@ -5,6 +5,7 @@ package resource_spotnodepool
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
@@ -12,6 +13,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
@@ -68,12 +70,62 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 				},
 			},
 			"bid_price": schema.Float64Attribute{
-				Required:            true,
-				Description:         "The bid price for the server in USD, rounded to three decimal places.",
-				MarkdownDescription: "The bid price for the server in USD, rounded to three decimal places.",
+				Optional:            true,
+				Computed:            true,
+				Description:         "The bid price for the server in USD, rounded to three decimal places. Either bid_price or bid_strategy must be set, it is computed from bid_strategy otherwise.",
+				MarkdownDescription: "The bid price for the server in USD, rounded to three decimal places. Either bid_price or bid_strategy must be set, it is computed from bid_strategy otherwise.",
 				Validators: []validator.Float64{
 					float64validator.AtLeast(0.001),
 					spotvalidator.DecimalDigitsAtMost(3),
+					float64validator.ExactlyOneOf(path.MatchRoot("bid_strategy")),
+				},
+			},
+			"bid_strategy": schema.SingleNestedAttribute{
+				Attributes: map[string]schema.Attribute{
+					"fraction_of_on_demand": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Bids the given fraction of the on-demand price of the server class, e.g. 0.5 for half of it.",
+						MarkdownDescription: "Bids the given fraction of the on-demand price of the server class, e.g. 0.5 for half of it.",
+						Validators: []validator.Float64{
+							float64validator.Between(0.001, 1),
+						},
+					},
+					"market_plus_absolute": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Bids the market price of the server class plus the given amount in USD.",
+						MarkdownDescription: "Bids the market price of the server class plus the given amount in USD.",
+						Validators: []validator.Float64{
+							float64validator.AtLeast(0),
+						},
+					},
+					"market_plus_percent": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "Bids the market price of the server class plus the given percentage, e.g. 10 for 110% of the market price.",
+						MarkdownDescription: "Bids the market price of the server class plus the given percentage, e.g. 10 for 110% of the market price.",
+						Validators: []validator.Float64{
+							float64validator.AtLeast(0),
+						},
+					},
+					"max_bid_price": schema.Float64Attribute{
+						Optional:            true,
+						Description:         "The bid price never exceeds this amount in USD, even when the strategy resolves to a higher price.",
+						MarkdownDescription: "The bid price never exceeds this amount in USD, even when the strategy resolves to a higher price.",
+						Validators: []validator.Float64{
+							float64validator.AtLeast(0.001),
+							spotvalidator.DecimalDigitsAtMost(3),
+						},
+					},
+				},
+				CustomType: BidStrategyType{
+					ObjectType: types.ObjectType{
+						AttrTypes: BidStrategyValue{}.AttributeTypes(ctx),
+					},
+				},
+				Optional:            true,
+				Description:         "Derives the bid price from the current pricing of the server class instead of a fixed bid_price. Exactly one strategy must be set, the resolved bid price is shown in the plan and follows the market on every apply.",
+				MarkdownDescription: "Derives the bid price from the current pricing of the server class instead of a fixed bid_price. Exactly one strategy must be set, the resolved bid price is shown in the plan and follows the market on every apply.",
+				Validators: []validator.Object{
+					objectvalidator.ExactlyOneOf(path.MatchRelative().AtName("fraction_of_on_demand"), path.MatchRelative().AtName("market_plus_absolute"), path.MatchRelative().AtName("market_plus_percent")),
 				},
 			},
 			"bid_status": schema.StringAttribute{
@@ -96,6 +148,13 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-zA-Z0-9]([-a-zA-Z0-9]*[a-zA-Z0-9])?$`), "Must be valid kubernetes name"),
 				},
 			},
+			"deletion_protection": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, the spot node pool can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the node pool.",
+				MarkdownDescription: "If true, the spot node pool can not be destroyed or replaced. It is stored in the state only, set it to false and apply before destroying the node pool.",
+				Default:             booldefault.StaticBool(false),
+			},
 			"desired_server_count": schema.Int64Attribute{
 				Optional:            true,
 				Computed:            true,
@@ -106,6 +165,16 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 					int64validator.ConflictsWith(path.MatchRelative().AtParent().AtName("autoscaling")),
 				},
 			},
+			"estimated_hourly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pool in USD per hour, the bid price times the desired number of servers, or the maximum number of servers when autoscaling is enabled. The actual cost depends on the market price.",
+				MarkdownDescription: "Estimated cost of the node pool in USD per hour, the bid price times the desired number of servers, or the maximum number of servers when autoscaling is enabled. The actual cost depends on the market price.",
+			},
+			"estimated_monthly_cost": schema.Float64Attribute{
+				Computed:            true,
+				Description:         "Estimated cost of the node pool in USD per month of 730 hours, see estimated_hourly_cost.",
+				MarkdownDescription: "Estimated cost of the node pool in USD per month of 730 hours, see estimated_hourly_cost.",
+			},
 			"id": schema.StringAttribute{
 				Computed:            true,
 				Description:         "The id of the spotnodepool.",
@@ -130,11 +199,30 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 				},
 			},
 			"name": schema.StringAttribute{
+				Optional:            true,
 				Computed:            true,
-				Description:         "The name of the spotnodepool.",
-				MarkdownDescription: "The name of the spotnodepool.",
+				Description:         "The name of the spotnodepool. It must consist of lowercase alphanumeric characters or '-', and changing it replaces the node pool. A random UUID is used if neither name nor name_prefix is set.",
+				MarkdownDescription: "The name of the spotnodepool. It must consist of lowercase alphanumeric characters or '-', and changing it replaces the node pool. A random UUID is used if neither name nor name_prefix is set.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 63),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`), "Must be a lowercase RFC 1123 label"),
+					stringvalidator.ConflictsWith(path.MatchRoot("name_prefix")),
+				},
+			},
+			"name_prefix": schema.StringAttribute{
+				Optional:            true,
+				Description:         "Creates a unique name beginning with the given prefix, followed by 8 random characters. Conflicts with name, changing it replaces the node pool.",
+				MarkdownDescription: "Creates a unique name beginning with the given prefix, followed by 8 random characters. Conflicts with name, changing it replaces the node pool.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 55),
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[a-z0-9][-a-z0-9]*$`), "Must start with a lowercase alphanumeric character followed by lowercase alphanumeric characters or '-'"),
 				},
 			},
 			"server_class": schema.StringAttribute{
@@ -177,6 +265,27 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 				Description:         "Kubernetes taints to be applied to the nodes of the node pool",
 				MarkdownDescription: "Kubernetes taints to be applied to the nodes of the node pool",
 			},
+			"wait_for_fulfillment": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, waits until the bid of the node pool is fulfilled, i.e. its desired or minimum number of servers are won, within the create or update timeout. The apply fails if the bid is lost and warns if it stays unfulfilled, both with the current market price of the server class. Can not be true along with wait_for_nodes, whose wait it includes.",
+				MarkdownDescription: "If true, waits until the bid of the node pool is fulfilled, i.e. its desired or minimum number of servers are won, within the create or update timeout. The apply fails if the bid is lost and warns if it stays unfulfilled, both with the current market price of the server class. Can not be true along with wait_for_nodes, whose wait it includes.",
+				Validators: []validator.Bool{
+					spotvalidator.TrueConflictsWith(path.MatchRoot("wait_for_nodes")),
+				},
+				Default: booldefault.StaticBool(false),
+			},
+			"timeouts": timeouts.Attributes(ctx, timeouts.Opts{
+				Create: true,
+				Update: true,
+			}),
+			"wait_for_nodes": schema.BoolAttribute{
+				Optional:            true,
+				Computed:            true,
+				Description:         "If true, waits until the node pool has won its desired or minimum number of servers, within the create or update timeout. Pending allocations are reported on timeout.",
+				MarkdownDescription: "If true, waits until the node pool has won its desired or minimum number of servers, within the create or update timeout. Pending allocations are reported on timeout.",
+				Default:             booldefault.StaticBool(false),
+			},
 			"won_count": schema.Int64Attribute{
 				Computed:            true,
 				Description:         "Number of won bids.",
@@ -190,19 +299,27 @@ func SpotnodepoolResourceSchema(ctx context.Context) schema.Schema {
 }
 
 type SpotnodepoolModel struct {
-	Annotations        types.Map        `tfsdk:"annotations"`
-	Autoscaling        AutoscalingValue `tfsdk:"autoscaling"`
-	BidPrice           types.Float64    `tfsdk:"bid_price"`
-	BidStatus          types.String     `tfsdk:"bid_status"`
-	CloudspaceName     types.String     `tfsdk:"cloudspace_name"`
-	DesiredServerCount types.Int64      `tfsdk:"desired_server_count"`
-	Id                 types.String     `tfsdk:"id"`
-	Labels             types.Map        `tfsdk:"labels"`
-	LastUpdated        types.String     `tfsdk:"last_updated"`
-	Name               types.String     `tfsdk:"name"`
-	ServerClass        types.String     `tfsdk:"server_class"`
-	Taints             types.List       `tfsdk:"taints"`
-	WonCount           types.Int64      `tfsdk:"won_count"`
+	Annotations          types.Map        `tfsdk:"annotations"`
+	Autoscaling          AutoscalingValue `tfsdk:"autoscaling"`
+	BidPrice             types.Float64    `tfsdk:"bid_price"`
+	BidStrategy          BidStrategyValue `tfsdk:"bid_strategy"`
+	BidStatus            types.String     `tfsdk:"bid_status"`
+	CloudspaceName       types.String     `tfsdk:"cloudspace_name"`
+	DeletionProtection   types.Bool       `tfsdk:"deletion_protection"`
+	DesiredServerCount   types.Int64      `tfsdk:"desired_server_count"`
+	EstimatedHourlyCost  types.Float64    `tfsdk:"estimated_hourly_cost"`
+	EstimatedMonthlyCost types.Float64    `tfsdk:"estimated_monthly_cost"`
+	Id                   types.String     `tfsdk:"id"`
+	Labels               types.Map        `tfsdk:"labels"`
+	LastUpdated          types.String     `tfsdk:"last_updated"`
+	Name                 types.String     `tfsdk:"name"`
+	NamePrefix           types.String     `tfsdk:"name_prefix"`
+	ServerClass          types.String     `tfsdk:"server_class"`
+	Taints               types.List       `tfsdk:"taints"`
+	WaitForFulfillment   types.Bool       `tfsdk:"wait_for_fulfillment"`
+	Timeouts             timeouts.Value   `tfsdk:"timeouts"`
+	WaitForNodes         types.Bool       `tfsdk:"wait_for_nodes"`
+	WonCount             types.Int64      `tfsdk:"won_count"`
 }
 
 var _ basetypes.ObjectTypable = AutoscalingType{}
@@ -574,6 +691,485 @@ func (v AutoscalingValue) AttributeTypes(ctx context.Context) map[string]attr.Ty
 	}
 }
 
+var _ basetypes.ObjectTypable = BidStrategyType{}
+
+type BidStrategyType struct {
+	basetypes.ObjectType
+}
+
+func (t BidStrategyType) Equal(o attr.Type) bool {
+	other, ok := o.(BidStrategyType)
+
+	if !ok {
+		return false
+	}
+
+	return t.ObjectType.Equal(other.ObjectType)
+}
+
+func (t BidStrategyType) String() string {
+	return "BidStrategyType"
+}
+
+func (t BidStrategyType) ValueFromObject(ctx context.Context, in basetypes.ObjectValue) (basetypes.ObjectValuable, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	attributes := in.Attributes()
+
+	fractionOfOnDemandAttribute, ok := attributes["fraction_of_on_demand"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`fraction_of_on_demand is missing from object`)
+
+		return nil, diags
+	}
+
+	fractionOfOnDemandVal, ok := fractionOfOnDemandAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`fraction_of_on_demand expected to be basetypes.Float64Value, was: %T`, fractionOfOnDemandAttribute))
+	}
+
+	marketPlusAbsoluteAttribute, ok := attributes["market_plus_absolute"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`market_plus_absolute is missing from object`)
+
+		return nil, diags
+	}
+
+	marketPlusAbsoluteVal, ok := marketPlusAbsoluteAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`market_plus_absolute expected to be basetypes.Float64Value, was: %T`, marketPlusAbsoluteAttribute))
+	}
+
+	marketPlusPercentAttribute, ok := attributes["market_plus_percent"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`market_plus_percent is missing from object`)
+
+		return nil, diags
+	}
+
+	marketPlusPercentVal, ok := marketPlusPercentAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`market_plus_percent expected to be basetypes.Float64Value, was: %T`, marketPlusPercentAttribute))
+	}
+
+	maxBidPriceAttribute, ok := attributes["max_bid_price"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_bid_price is missing from object`)
+
+		return nil, diags
+	}
+
+	maxBidPriceVal, ok := maxBidPriceAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_bid_price expected to be basetypes.Float64Value, was: %T`, maxBidPriceAttribute))
+	}
+
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return BidStrategyValue{
+		FractionOfOnDemand: fractionOfOnDemandVal,
+		MarketPlusAbsolute: marketPlusAbsoluteVal,
+		MarketPlusPercent:  marketPlusPercentVal,
+		MaxBidPrice:        maxBidPriceVal,
+		state:              attr.ValueStateKnown,
+	}, diags
+}
+
+func NewBidStrategyValueNull() BidStrategyValue {
+	return BidStrategyValue{
+		state: attr.ValueStateNull,
+	}
+}
+
+func NewBidStrategyValueUnknown() BidStrategyValue {
+	return BidStrategyValue{
+		state: attr.ValueStateUnknown,
+	}
+}
+
+func NewBidStrategyValue(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) (BidStrategyValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	// Reference: https://github.com/hashicorp/terraform-plugin-framework/issues/521
+	ctx := context.Background()
+
+	for name, attributeType := range attributeTypes {
+		attribute, ok := attributes[name]
+
+		if !ok {
+			diags.AddError(
+				"Missing BidStrategyValue Attribute Value",
+				"While creating a BidStrategyValue value, a missing attribute value was detected. "+
+					"A BidStrategyValue must contain values for all attributes, even if null or unknown. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("BidStrategyValue Attribute Name (%s) Expected Type: %s", name, attributeType.String()),
+			)
+
+			continue
+		}
+
+		if !attributeType.Equal(attribute.Type(ctx)) {
+			diags.AddError(
+				"Invalid BidStrategyValue Attribute Type",
+				"While creating a BidStrategyValue value, an invalid attribute value was detected. "+
+					"A BidStrategyValue must use a matching attribute type for the value. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("BidStrategyValue Attribute Name (%s) Expected Type: %s\n", name, attributeType.String())+
+					fmt.Sprintf("BidStrategyValue Attribute Name (%s) Given Type: %s", name, attribute.Type(ctx)),
+			)
+		}
+	}
+
+	for name := range attributes {
+		_, ok := attributeTypes[name]
+
+		if !ok {
+			diags.AddError(
+				"Extra BidStrategyValue Attribute Value",
+				"While creating a BidStrategyValue value, an extra attribute value was detected. "+
+					"A BidStrategyValue must not contain values beyond the expected attribute types. "+
+					"This is always an issue with the provider and should be reported to the provider developers.\n\n"+
+					fmt.Sprintf("Extra BidStrategyValue Attribute Name: %s", name),
+			)
+		}
+	}
+
+	if diags.HasError() {
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	fractionOfOnDemandAttribute, ok := attributes["fraction_of_on_demand"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`fraction_of_on_demand is missing from object`)
+
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	fractionOfOnDemandVal, ok := fractionOfOnDemandAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`fraction_of_on_demand expected to be basetypes.Float64Value, was: %T`, fractionOfOnDemandAttribute))
+	}
+
+	marketPlusAbsoluteAttribute, ok := attributes["market_plus_absolute"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`market_plus_absolute is missing from object`)
+
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	marketPlusAbsoluteVal, ok := marketPlusAbsoluteAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`market_plus_absolute expected to be basetypes.Float64Value, was: %T`, marketPlusAbsoluteAttribute))
+	}
+
+	marketPlusPercentAttribute, ok := attributes["market_plus_percent"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`market_plus_percent is missing from object`)
+
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	marketPlusPercentVal, ok := marketPlusPercentAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`market_plus_percent expected to be basetypes.Float64Value, was: %T`, marketPlusPercentAttribute))
+	}
+
+	maxBidPriceAttribute, ok := attributes["max_bid_price"]
+
+	if !ok {
+		diags.AddError(
+			"Attribute Missing",
+			`max_bid_price is missing from object`)
+
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	maxBidPriceVal, ok := maxBidPriceAttribute.(basetypes.Float64Value)
+
+	if !ok {
+		diags.AddError(
+			"Attribute Wrong Type",
+			fmt.Sprintf(`max_bid_price expected to be basetypes.Float64Value, was: %T`, maxBidPriceAttribute))
+	}
+
+	if diags.HasError() {
+		return NewBidStrategyValueUnknown(), diags
+	}
+
+	return BidStrategyValue{
+		FractionOfOnDemand: fractionOfOnDemandVal,
+		MarketPlusAbsolute: marketPlusAbsoluteVal,
+		MarketPlusPercent:  marketPlusPercentVal,
+		MaxBidPrice:        maxBidPriceVal,
+		state:              attr.ValueStateKnown,
+	}, diags
+}
+
+func NewBidStrategyValueMust(attributeTypes map[string]attr.Type, attributes map[string]attr.Value) BidStrategyValue {
+	object, diags := NewBidStrategyValue(attributeTypes, attributes)
+
+	if diags.HasError() {
+		// This could potentially be added to the diag package.
+		diagsStrings := make([]string, 0, len(diags))
+
+		for _, diagnostic := range diags {
+			diagsStrings = append(diagsStrings, fmt.Sprintf(
+				"%s | %s | %s",
+				diagnostic.Severity(),
+				diagnostic.Summary(),
+				diagnostic.Detail()))
+		}
+
+		panic("NewBidStrategyValueMust received error(s): " + strings.Join(diagsStrings, "\n"))
+	}
+
+	return object
+}
+
+func (t BidStrategyType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	if in.Type() == nil {
+		return NewBidStrategyValueNull(), nil
+	}
+
+	if !in.Type().Equal(t.TerraformType(ctx)) {
+		return nil, fmt.Errorf("expected %s, got %s", t.TerraformType(ctx), in.Type())
+	}
+
+	if !in.IsKnown() {
+		return NewBidStrategyValueUnknown(), nil
+	}
+
+	if in.IsNull() {
+		return NewBidStrategyValueNull(), nil
+	}
+
+	attributes := map[string]attr.Value{}
+
+	val := map[string]tftypes.Value{}
+
+	err := in.As(&val)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range val {
+		a, err := t.AttrTypes[k].ValueFromTerraform(ctx, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		attributes[k] = a
+	}
+
+	return NewBidStrategyValueMust(BidStrategyValue{}.AttributeTypes(ctx), attributes), nil
+}
+
+func (t BidStrategyType) ValueType(ctx context.Context) attr.Value {
+	return BidStrategyValue{}
+}
+
+var _ basetypes.ObjectValuable = BidStrategyValue{}
+
+type BidStrategyValue struct {
+	FractionOfOnDemand basetypes.Float64Value `tfsdk:"fraction_of_on_demand"`
+	MarketPlusAbsolute basetypes.Float64Value `tfsdk:"market_plus_absolute"`
+	MarketPlusPercent  basetypes.Float64Value `tfsdk:"market_plus_percent"`
+	MaxBidPrice        basetypes.Float64Value `tfsdk:"max_bid_price"`
+	state              attr.ValueState
+}
+
+func (v BidStrategyValue) ToTerraformValue(ctx context.Context) (tftypes.Value, error) {
+	attrTypes := make(map[string]tftypes.Type, 4)
+
+	var val tftypes.Value
+	var err error
+
+	attrTypes["fraction_of_on_demand"] = basetypes.Float64Type{}.TerraformType(ctx)
+	attrTypes["market_plus_absolute"] = basetypes.Float64Type{}.TerraformType(ctx)
+	attrTypes["market_plus_percent"] = basetypes.Float64Type{}.TerraformType(ctx)
+	attrTypes["max_bid_price"] = basetypes.Float64Type{}.TerraformType(ctx)
+
+	objectType := tftypes.Object{AttributeTypes: attrTypes}
+
+	switch v.state {
+	case attr.ValueStateKnown:
+		vals := make(map[string]tftypes.Value, 4)
+
+		val, err = v.FractionOfOnDemand.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["fraction_of_on_demand"] = val
+
+		val, err = v.MarketPlusAbsolute.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["market_plus_absolute"] = val
+
+		val, err = v.MarketPlusPercent.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["market_plus_percent"] = val
+
+		val, err = v.MaxBidPrice.ToTerraformValue(ctx)
+
+		if err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		vals["max_bid_price"] = val
+
+		if err := tftypes.ValidateValue(objectType, vals); err != nil {
+			return tftypes.NewValue(objectType, tftypes.UnknownValue), err
+		}
+
+		return tftypes.NewValue(objectType, vals), nil
+	case attr.ValueStateNull:
+		return tftypes.NewValue(objectType, nil), nil
+	case attr.ValueStateUnknown:
+		return tftypes.NewValue(objectType, tftypes.UnknownValue), nil
+	default:
+		panic(fmt.Sprintf("unhandled Object state in ToTerraformValue: %s", v.state))
+	}
+}
+
+func (v BidStrategyValue) IsNull() bool {
+	return v.state == attr.ValueStateNull
+}
+
+func (v BidStrategyValue) IsUnknown() bool {
+	return v.state == attr.ValueStateUnknown
+}
+
+func (v BidStrategyValue) String() string {
+	return "BidStrategyValue"
+}
+
+func (v BidStrategyValue) ToObjectValue(ctx context.Context) (basetypes.ObjectValue, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	objVal, diags := types.ObjectValue(
+		map[string]attr.Type{
+			"fraction_of_on_demand": basetypes.Float64Type{},
+			"market_plus_absolute":  basetypes.Float64Type{},
+			"market_plus_percent":   basetypes.Float64Type{},
+			"max_bid_price":         basetypes.Float64Type{},
+		},
+		map[string]attr.Value{
+			"fraction_of_on_demand": v.FractionOfOnDemand,
+			"market_plus_absolute":  v.MarketPlusAbsolute,
+			"market_plus_percent":   v.MarketPlusPercent,
+			"max_bid_price":         v.MaxBidPrice,
+		})
+
+	return objVal, diags
+}
+
+func (v BidStrategyValue) Equal(o attr.Value) bool {
+	other, ok := o.(BidStrategyValue)
+
+	if !ok {
+		return false
+	}
+
+	if v.state != other.state {
+		return false
+	}
+
+	if v.state != attr.ValueStateKnown {
+		return true
+	}
+
+	if !v.FractionOfOnDemand.Equal(other.FractionOfOnDemand) {
+		return false
+	}
+
+	if !v.MarketPlusAbsolute.Equal(other.MarketPlusAbsolute) {
+		return false
+	}
+
+	if !v.MarketPlusPercent.Equal(other.MarketPlusPercent) {
+		return false
+	}
+
+	if !v.MaxBidPrice.Equal(other.MaxBidPrice) {
+		return false
+	}
+
+	return true
+}
+
+func (v BidStrategyValue) Type(ctx context.Context) attr.Type {
+	return BidStrategyType{
+		basetypes.ObjectType{
+			AttrTypes: v.AttributeTypes(ctx),
+		},
+	}
+}
+
+func (v BidStrategyValue) AttributeTypes(ctx context.Context) map[string]attr.Type {
+	return map[string]attr.Type{
+		"fraction_of_on_demand": basetypes.Float64Type{},
+		"market_plus_absolute":  basetypes.Float64Type{},
+		"market_plus_percent":   basetypes.Float64Type{},
+		"max_bid_price":         basetypes.Float64Type{},
+	}
+}
+
 var _ basetypes.ObjectTypable = TaintsType{}
 
 type TaintsType struct {
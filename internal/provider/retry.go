@@ -1,12 +1,267 @@
 package provider
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
 	"time"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/watch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/rackerlabs/terraform-provider-spot/internal/provider/provider_spot"
 )
 
 const (
 	// DefaultCloudSpaceCreateTimeout is the default timeout for creating a control plane for a cloud space.
 	DefaultCloudSpaceCreateTimeout = 5 * time.Minute
+	// DefaultCloudSpaceUpdateTimeout is the default timeout for upgrading the control plane of a cloud space.
+	DefaultCloudSpaceUpdateTimeout = 30 * time.Minute
+	// DefaultCloudSpaceDeleteTimeout is the default timeout for a cloud space to be deleted.
+	DefaultCloudSpaceDeleteTimeout = 10 * time.Minute
+	// DefaultNodePoolCreateTimeout is the default timeout for the nodes of a new node pool.
+	DefaultNodePoolCreateTimeout = 20 * time.Minute
+	// DefaultNodePoolUpdateTimeout is the default timeout for the nodes of an updated node pool.
+	DefaultNodePoolUpdateTimeout = 20 * time.Minute
+	// DefaultKubeconfigReadyTimeout is the default timeout for the control plane of a cloud space
+	// to be ready when reading its kubeconfig.
+	DefaultKubeconfigReadyTimeout = 150 * time.Second
+	// controlPlaneVersionTimeout bounds the time spent reading the version of the control plane of a cloud space.
+	controlPlaneVersionTimeout = 30 * time.Second
 	// DefaultRefreshInterval is the default interval at which the provider will poll the API for updates.
 	DefaultRefreshInterval = 5 * time.Second
+
+	// DefaultRetryMaxAttempts is the default number of attempts of an API call, including the first one.
+	DefaultRetryMaxAttempts = 5
+	// DefaultRetryMaxElapsed is the default maximum total duration of the attempts of an API call.
+	DefaultRetryMaxElapsed = 2 * time.Minute
+	// DefaultRetryBaseDelay is the default delay before the first retry of an API call.
+	DefaultRetryBaseDelay = 1 * time.Second
+	// DefaultRetryJitter is the default randomization factor applied to the retry delays.
+	DefaultRetryJitter = 0.5
+	// retryMaxDelay caps the exponentially growing delay between two attempts.
+	retryMaxDelay = 30 * time.Second
 )
+
+// retryPolicy controls how API calls failing with transient errors are retried
+type retryPolicy struct {
+	maxAttempts int
+	maxElapsed  time.Duration
+	baseDelay   time.Duration
+	jitter      float64
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxAttempts: DefaultRetryMaxAttempts,
+		maxElapsed:  DefaultRetryMaxElapsed,
+		baseDelay:   DefaultRetryBaseDelay,
+		jitter:      DefaultRetryJitter,
+	}
+}
+
+// newRetryPolicy returns the retry policy configured in the provider retry block,
+// unset attributes take the default values.
+func newRetryPolicy(retry provider_spot.RetryValue) (retryPolicy, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	policy := defaultRetryPolicy()
+	if retry.IsNull() || retry.IsUnknown() {
+		return policy, diags
+	}
+	if !retry.MaxAttempts.IsNull() && !retry.MaxAttempts.IsUnknown() {
+		policy.maxAttempts = int(retry.MaxAttempts.ValueInt64())
+	}
+	if !retry.Jitter.IsNull() && !retry.Jitter.IsUnknown() {
+		policy.jitter = retry.Jitter.ValueFloat64()
+	}
+	parseDuration := func(name string, val string, target *time.Duration) {
+		if val == "" {
+			return
+		}
+		duration, err := time.ParseDuration(val)
+		if err != nil || duration <= 0 {
+			diags.AddAttributeError(path.Root("retry").AtName(name), "Invalid duration",
+				fmt.Sprintf("%q is not a valid positive duration, use a value like \"30s\"", val))
+			return
+		}
+		*target = duration
+	}
+	parseDuration("max_elapsed", retry.MaxElapsed.ValueString(), &policy.maxElapsed)
+	parseDuration("base_delay", retry.BaseDelay.ValueString(), &policy.baseDelay)
+	return policy, diags
+}
+
+// retry calls the operation until it succeeds, fails with an error that is not transient,
+// or the attempts or the elapsed time of the policy are exhausted.
+func (p retryPolicy) retry(ctx context.Context, verb string, obj any, operation func() error) error {
+	expBackOff := backoff.NewExponentialBackOff()
+	expBackOff.InitialInterval = p.baseDelay
+	expBackOff.RandomizationFactor = p.jitter
+	expBackOff.MaxInterval = retryMaxDelay
+	expBackOff.MaxElapsedTime = p.maxElapsed
+	var strategy backoff.BackOff = expBackOff
+	if p.maxAttempts > 0 {
+		strategy = backoff.WithMaxRetries(strategy, uint64(p.maxAttempts-1))
+	}
+	attempt := 1
+	return backoff.RetryNotify(func() error {
+		err := operation()
+		if err != nil && !isRetryableError(verb, obj, err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(strategy, ctx), func(err error, delay time.Duration) {
+		tflog.Warn(ctx, "Retrying API call after transient error", map[string]any{
+			"verb":    verb,
+			"kind":    fmt.Sprintf("%T", obj),
+			"attempt": attempt,
+			"delay":   delay.String(),
+			"error":   err.Error(),
+		})
+		attempt++
+	})
+}
+
+// isRetryableError classifies the error of an API call as transient, such as throttling,
+// server errors, connection resets and conflicts.
+func isRetryableError(verb string, obj any, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsInternalError(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsUnexpectedServerError(err):
+		return true
+	case apierrors.IsConflict(err):
+		// Retrying an update or patch carrying a resourceVersion fails the same way,
+		// the object has to be read again before changing it
+		if o, ok := obj.(client.Object); ok && (verb == "update" || verb == "patch") && o.GetResourceVersion() != "" {
+			return false
+		}
+		return true
+	}
+	var statusErr apierrors.APIStatus
+	if errors.As(err, &statusErr) {
+		return statusErr.Status().Code >= 500
+	}
+	if utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err) || utilnet.IsConnectionRefused(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isAmbiguousError returns true if the failed call may have been processed by the server,
+// which is the case for transient errors other than throttling and refused connections.
+func isAmbiguousError(err error) bool {
+	return isRetryableError("create", nil, err) && !apierrors.IsTooManyRequests(err) &&
+		!apierrors.IsConflict(err) && !utilnet.IsConnectionRefused(err)
+}
+
+var _ ngpc.Client = (*retryingClient)(nil)
+
+// retryingClient retries the calls of the wrapped ngpc client according to the retry policy.
+// Calls not overridden here are passed through as is.
+type retryingClient struct {
+	ngpc.Client
+	policy retryPolicy
+}
+
+func newRetryingClient(c ngpc.Client, policy retryPolicy) ngpc.Client {
+	return &retryingClient{Client: c, policy: policy}
+}
+
+func (c *retryingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	return c.policy.retry(ctx, "get", obj, func() error {
+		return c.Client.Get(ctx, key, obj, opts...)
+	})
+}
+
+func (c *retryingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.policy.retry(ctx, "list", list, func() error {
+		return c.Client.List(ctx, list, opts...)
+	})
+}
+
+// createRequestAnnotation is set on created objects to the id of the create call, so an object found after
+// a failed attempt is only taken for the one created by the call if it carries the same id.
+const createRequestAnnotation = "terraform-provider-spot/create-request"
+
+// Create retries the creation of the object. An attempt failing without a response may still have
+// created the object, hence AlreadyExists after such a failure means that the object was created if
+// the existing object has the create request annotation of this call, it is read into obj then.
+// An object of the same name which existed before is not adopted, the AlreadyExists error is returned.
+func (c *retryingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	requestID, err := generateRandomUUID()
+	if err != nil {
+		return fmt.Errorf("failed to generate the create request id: %w", err)
+	}
+	annotations := make(map[string]string, len(obj.GetAnnotations())+1)
+	for key, value := range obj.GetAnnotations() {
+		annotations[key] = value
+	}
+	annotations[createRequestAnnotation] = requestID
+	obj.SetAnnotations(annotations)
+
+	var ambiguous bool
+	return c.policy.retry(ctx, "create", obj, func() error {
+		err := c.Client.Create(ctx, obj, opts...)
+		if ambiguous && apierrors.IsAlreadyExists(err) {
+			if getErr := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); getErr != nil {
+				return getErr
+			}
+			if obj.GetAnnotations()[createRequestAnnotation] != requestID {
+				return err
+			}
+			tflog.Warn(ctx, "Object already exists after a failed create attempt, it was created by the attempt", map[string]any{
+				"kind": fmt.Sprintf("%T", obj),
+				"name": obj.GetName(),
+			})
+			return nil
+		}
+		if err != nil && isAmbiguousError(err) {
+			ambiguous = true
+		}
+		return err
+	})
+}
+
+func (c *retryingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.policy.retry(ctx, "update", obj, func() error {
+		return c.Client.Update(ctx, obj, opts...)
+	})
+}
+
+func (c *retryingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.policy.retry(ctx, "patch", obj, func() error {
+		return c.Client.Patch(ctx, obj, patch, opts...)
+	})
+}
+
+// Watch passes the watch to the wrapped client if it supports watches. Watches are not retried,
+// waits fall back to polling if a watch can not be started.
+func (c *retryingClient) Watch(ctx context.Context, list client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	watcher, ok := c.Client.(client.WithWatch)
+	if !ok {
+		return nil, errors.New("the client does not support watches")
+	}
+	return watcher.Watch(ctx, list, opts...)
+}
+
+func (c *retryingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	return c.policy.retry(ctx, "delete", obj, func() error {
+		return c.Client.Delete(ctx, obj, opts...)
+	})
+}
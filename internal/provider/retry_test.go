@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryableError(t *testing.T) {
+	resource := schema.GroupResource{Group: "ngpc.rxt.io", Resource: "spotnodepools"}
+	versioned := &ngpcv1.SpotNodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool", ResourceVersion: "42"}}
+	unversioned := &ngpcv1.SpotNodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool"}}
+
+	tests := []struct {
+		name string
+		verb string
+		obj  any
+		err  error
+		want bool
+	}{
+		{"too many requests", "get", unversioned, apierrors.NewTooManyRequests("slow down", 1), true},
+		{"internal error", "get", unversioned, apierrors.NewInternalError(errors.New("boom")), true},
+		{"service unavailable", "list", unversioned, apierrors.NewServiceUnavailable("unavailable"), true},
+		{"server timeout", "get", unversioned, apierrors.NewServerTimeout(resource, "get", 1), true},
+		{"gateway timeout", "get", unversioned, apierrors.NewTimeoutError("timeout", 1), true},
+		{"other 5xx", "get", unversioned, apierrors.NewGenericServerResponse(502, "get", resource, "pool", "bad gateway", 0, true), true},
+		{"not found", "get", unversioned, apierrors.NewNotFound(resource, "pool"), false},
+		{"bad request", "create", unversioned, apierrors.NewBadRequest("invalid"), false},
+		{"forbidden", "get", unversioned, apierrors.NewForbidden(resource, "pool", errors.New("denied")), false},
+		{"already exists", "create", unversioned, apierrors.NewAlreadyExists(resource, "pool"), false},
+		{"conflict on create", "create", unversioned, apierrors.NewConflict(resource, "pool", errors.New("conflict")), true},
+		{"conflict on update without resource version", "update", unversioned, apierrors.NewConflict(resource, "pool", errors.New("conflict")), true},
+		{"conflict on update with resource version", "update", versioned, apierrors.NewConflict(resource, "pool", errors.New("conflict")), false},
+		{"conflict on patch with resource version", "patch", versioned, apierrors.NewConflict(resource, "pool", errors.New("conflict")), false},
+		{"connection reset", "get", unversioned, fmt.Errorf("read tcp: %w", syscall.ECONNRESET), true},
+		{"connection refused", "get", unversioned, fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED), true},
+		{"unexpected eof", "get", unversioned, io.EOF, true},
+		{"network timeout", "get", unversioned, fmt.Errorf("get: %w", timeoutError{}), true},
+		{"context canceled", "get", unversioned, context.Canceled, false},
+		{"context deadline exceeded", "get", unversioned, fmt.Errorf("get: %w", context.DeadlineExceeded), false},
+		{"other error", "get", unversioned, errors.New("invalid object"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.verb, tt.obj, tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%q, %v) = %v, want %v", tt.verb, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// createClient fails the first create call with createErr, creating the object anyway if createdOnError is set.
+// Later calls fail with AlreadyExists once an object exists, which may be another object of the same name.
+type createClient struct {
+	ngpc.Client
+	createErr      error
+	createdOnError bool
+	existing       client.Object
+	creates        int
+}
+
+func (c *createClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.creates++
+	if c.creates == 1 && c.createErr != nil {
+		if c.createdOnError {
+			c.existing = obj.DeepCopyObject().(client.Object)
+		}
+		return c.createErr
+	}
+	if c.existing != nil {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Resource: "spotnodepools"}, obj.GetName())
+	}
+	c.existing = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func (c *createClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if c.existing == nil {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "spotnodepools"}, key.Name)
+	}
+	obj.SetAnnotations(c.existing.GetAnnotations())
+	obj.SetResourceVersion("1")
+	return nil
+}
+
+func TestRetryingClientCreate(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 3, maxElapsed: time.Second, baseDelay: time.Millisecond}
+	tests := []struct {
+		name           string
+		createErr      error
+		createdOnError bool
+		existing       client.Object
+		wantErr        bool
+		wantCreates    int
+	}{
+		{
+			name:        "created",
+			wantCreates: 1,
+		},
+		{
+			name:           "created although the response was lost",
+			createErr:      fmt.Errorf("read tcp: %w", syscall.ECONNRESET),
+			createdOnError: true,
+			wantCreates:    2,
+		},
+		{
+			name:           "created although the server failed",
+			createErr:      apierrors.NewInternalError(errors.New("boom")),
+			createdOnError: true,
+			wantCreates:    2,
+		},
+		{
+			name:        "created on retry",
+			createErr:   fmt.Errorf("read tcp: %w", syscall.ECONNRESET),
+			wantCreates: 2,
+		},
+		{
+			name:        "another object of the same name exists",
+			createErr:   fmt.Errorf("read tcp: %w", syscall.ECONNRESET),
+			existing:    &ngpcv1.SpotNodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "org"}},
+			wantErr:     true,
+			wantCreates: 2,
+		},
+		{
+			name:           "already exists after throttling",
+			createErr:      apierrors.NewTooManyRequests("slow down", 0),
+			createdOnError: true,
+			wantErr:        true,
+			wantCreates:    2,
+		},
+		{
+			name:        "not retried",
+			createErr:   apierrors.NewBadRequest("invalid"),
+			wantErr:     true,
+			wantCreates: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &createClient{createErr: tt.createErr, createdOnError: tt.createdOnError, existing: tt.existing}
+			c := &retryingClient{Client: fake, policy: policy}
+			pool := &ngpcv1.SpotNodePool{ObjectMeta: metav1.ObjectMeta{Name: "pool", Namespace: "org"}}
+			err := c.Create(context.Background(), pool)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && tt.existing != nil && !apierrors.IsAlreadyExists(err) {
+				t.Errorf("Create() error = %v, want AlreadyExists", err)
+			}
+			if fake.creates != tt.wantCreates {
+				t.Errorf("Create() called %d times, want %d", fake.creates, tt.wantCreates)
+			}
+			if !tt.wantErr && tt.createdOnError && pool.ResourceVersion != "1" {
+				t.Errorf("the existing object was not read back")
+			}
+		})
+	}
+}
@@ -48,10 +48,19 @@ func (d *serverclassDataSource) Configure(ctx context.Context, req datasource.Co
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
 }
 
 func (d *serverclassDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_serverclass.ServerclassModel
 
 	// Read Terraform configuration data into the model
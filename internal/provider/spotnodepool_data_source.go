@@ -28,6 +28,7 @@ func NewSpotnodepoolDataSource() datasource.DataSource {
 
 type spotnodepoolDataSource struct {
 	ngpcClient ngpc.Client
+	namespace  string
 }
 
 func (d *spotnodepoolDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -52,10 +53,20 @@ func (d *spotnodepoolDataSource) Configure(ctx context.Context, req datasource.C
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	d.ngpcClient = spotProviderData.ngpcClient
+	d.namespace = spotProviderData.namespace
 }
 
 func (d *spotnodepoolDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if !checkProviderConfigured(d.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data datasource_spotnodepool.SpotnodepoolModel
 
 	// Read Terraform configuration data into the model
@@ -70,11 +81,7 @@ func (d *spotnodepoolDataSource) Read(ctx context.Context, req datasource.ReadRe
 		resp.Diagnostics.AddError("Failed to get name from id", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace from env", err.Error())
-		return
-	}
+	namespace := d.namespace
 	// Read API call logic
 	tflog.Info(ctx, "Getting spotnodepool", map[string]any{"name": name, "namespace": namespace})
 	spotNodePool := &ngpcv1.SpotNodePool{}
@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -19,8 +20,10 @@ import (
 	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
 	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ktypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
@@ -36,6 +39,8 @@ func NewSpotnodepoolResource() resource.Resource {
 
 type spotnodepoolResource struct {
 	ngpcClient ngpc.Client
+	namespace  string
+	budget     spendBudget
 }
 
 func (r *spotnodepoolResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -60,34 +65,109 @@ func (r *spotnodepoolResource) Configure(ctx context.Context, req resource.Confi
 		return
 	}
 
+	resp.Diagnostics.Append(spotProviderData.initialize(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	r.ngpcClient = spotProviderData.ngpcClient
+	r.namespace = spotProviderData.namespace
+	r.budget = spotProviderData.budget
 }
 
 func (r *spotnodepoolResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	planDeletionProtection(ctx, req, resp, "spot node pool", nodePoolReplaceAttributes)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if req.Plan.Raw.IsNull() {
+		planNodePoolBudget(ctx, req, resp, r.ngpcClient, r.namespace, r.budget, "spotnodepool")
+		return
+	}
+
 	var serverClassVal types.String
 	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribServerClass), &serverClassVal)...)
-	if !serverClassVal.IsNull() && !serverClassVal.IsUnknown() {
+	var serverClass *ngpcv1.ServerClass
+	// Validation is skipped if the provider configuration is not known yet
+	if !serverClassVal.IsNull() && !serverClassVal.IsUnknown() && r.ngpcClient != nil {
 		serverClasssList, err := listServerClasses(ctx, r.ngpcClient)
 		if err != nil {
 			resp.Diagnostics.AddWarning("Failed to list server classes", err.Error())
 		} else {
-			var validServerClass bool
-			for _, serverClass := range serverClasssList {
-				if serverClass.Name == serverClassVal.ValueString() {
-					validServerClass = true
+			for i := range serverClasssList {
+				if serverClasssList[i].Name == serverClassVal.ValueString() {
+					serverClass = &serverClasssList[i]
 					break
 				}
 			}
-			if !validServerClass {
+			if serverClass == nil {
 				resp.Diagnostics.AddAttributeError(path.Root(attribServerClass), "Invalid value",
 					"The valid values should be read from the serverclasses data source.")
 				return
 			}
 		}
 	}
+
+	var bidStrategy resource_spotnodepool.BidStrategyValue
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root(attribBidStrategy), &bidStrategy)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !bidStrategy.IsNull() {
+		// The bid price follows the market, it is resolved on every plan so the new price shows up
+		// in the plan. It is resolved on apply if the server class or the strategy are not known yet.
+		bidPrice := types.Float64Unknown()
+		if serverClass != nil && isBidStrategyKnown(bidStrategy) {
+			price, err := bidPriceFromStrategy(serverClass, bidStrategy)
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root(attribBidStrategy), "Failed to resolve the bid strategy", err.Error())
+				return
+			}
+			tflog.Debug(ctx, "Resolved the bid strategy", map[string]any{"serverClass": serverClass.Name, "bidPrice": price})
+			bidPrice = types.Float64Value(price)
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribBidPrice), bidPrice)...)
+	}
+
+	var plan resource_spotnodepool.SpotnodepoolModel
+	resp.Diagnostics.Append(resp.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	hourlyCost, monthlyCost := plannedSpotnodepoolCosts(&plan)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribEstimatedHourlyCost), hourlyCost)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root(attribEstimatedMonthlyCost), monthlyCost)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Bid prices are checked when they change only, like the spend below
+	var priorBidPrice types.Float64
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root(attribBidPrice), &priorBidPrice)...)
+	}
+	if !plan.BidPrice.IsUnknown() && !plan.BidPrice.Equal(priorBidPrice) {
+		checkBidPriceBudget(r.budget, plan.BidPrice.ValueFloat64(), &resp.Diagnostics)
+	}
+	planNodePoolBudget(ctx, req, resp, r.ngpcClient, r.namespace, r.budget, "spotnodepool")
+}
+
+// plannedSpotnodepoolCosts estimates the costs of the planned node pool,
+// they are unknown until the bid price and the number of servers are known.
+func plannedSpotnodepoolCosts(plan *resource_spotnodepool.SpotnodepoolModel) (types.Float64, types.Float64) {
+	if plan.BidPrice.IsUnknown() || plan.DesiredServerCount.IsUnknown() || plan.Autoscaling.IsUnknown() ||
+		plan.Autoscaling.MaxNodes.IsUnknown() {
+		return types.Float64Unknown(), types.Float64Unknown()
+	}
+	autoscalingSpec, _ := convertAutoscalingValueToSpec(plan.Autoscaling)
+	return estimatedCosts(spotNodePoolHourlyCost(plan.BidPrice.ValueFloat64(), int(plan.DesiredServerCount.ValueInt64()), autoscalingSpec))
 }
 
 func (r *spotnodepoolResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_spotnodepool.SpotnodepoolModel
 
 	// Read Terraform plan data into the model
@@ -97,18 +177,18 @@ func (r *spotnodepoolResource) Create(ctx context.Context, req resource.CreateRe
 		return
 	}
 
-	name, err := generateRandomUUID()
+	name, err := newNodePoolName(data.Name, data.NamePrefix)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to generate random UUID", err.Error())
-		return
-	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
+		resp.Diagnostics.AddError("Failed to generate name", err.Error())
 		return
 	}
+	namespace := r.namespace
 
 	tflog.Debug(ctx, "Creating spotnodepool", map[string]any{"name": name, "namespace": namespace})
+	resp.Diagnostics.Append(r.resolveBidPrice(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	strBidPrice := fmt.Sprintf("%.3f", data.BidPrice.ValueFloat64())
 
 	// Prepare custom metadata
@@ -185,25 +265,46 @@ func (r *spotnodepoolResource) Create(ctx context.Context, req resource.CreateRe
 		}
 	}
 	tflog.Debug(ctx, "Creating spotnodepool", map[string]any{"name": spotNodePool.ObjectMeta.Name})
-	err = r.ngpcClient.Create(ctx, spotNodePool)
+	err = r.ngpcClient.Create(ctx, spotNodePool, client.FieldOwner(fieldManager))
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to create nodepool", err.Error())
 		return
 	}
 	tflog.Debug(ctx, "Created spotnodepool", map[string]any{"name": spotNodePool.ObjectMeta.Name})
+	// Node pools planned after this one count it as existing from now on
+	r.budget.planned.markCreated("spotnodepool", name, data.EstimatedHourlyCost.ValueFloat64())
 	resp.Diagnostics.Append(setSpotnodepoolState(ctx, spotNodePool, &data)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 	data.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(spotNodePool.ObjectMeta.ResourceVersion))...)
+	// The name of the replaced node pool is only used during plan
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyReplacedName, nil)...)
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 	tflog.Debug(ctx, "Updated local state by getting remote api object", map[string]any{"name": spotNodePool.ObjectMeta.Name})
+
+	if data.WaitForFulfillment.ValueBool() || data.WaitForNodes.ValueBool() {
+		createTimeout, diags := data.Timeouts.Create(ctx, DefaultNodePoolCreateTimeout)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+		if data.WaitForFulfillment.ValueBool() {
+			resp.Diagnostics.Append(r.waitForFulfillment(ctx, name, namespace, &data, createTimeout)...)
+		} else {
+			resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, data.CloudspaceName.ValueString(), createTimeout)...)
+		}
+	}
 }
 
 func (r *spotnodepoolResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if !checkProviderConfiguredForRead(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_spotnodepool.SpotnodepoolModel
 
 	// Read Terraform prior state data into the model
@@ -219,15 +320,18 @@ func (r *spotnodepoolResource) Read(ctx context.Context, req resource.ReadReques
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	tflog.Info(ctx, "Getting spotnodepool", map[string]any{"name": name, "namespace": namespace})
 	spotNodePool := &ngpcv1.SpotNodePool{}
 	err = r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, spotNodePool)
+	if apierrors.IsNotFound(err) {
+		// Deleted outside of terraform, removing it from the state makes terraform plan a recreate
+		resp.Diagnostics.AddWarning("Spotnodepool not found",
+			fmt.Sprintf("Spotnodepool %s no longer exists and is removed from the state", name))
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get spotnodepool", err.Error())
 		return
@@ -237,6 +341,16 @@ func (r *spotnodepoolResource) Read(ctx context.Context, req resource.ReadReques
 		return
 	}
 	data.LastUpdated = types.StringNull()
+	if data.DeletionProtection.IsNull() {
+		// Not known after an import
+		data.DeletionProtection = types.BoolValue(false)
+	}
+	if data.WaitForNodes.IsNull() {
+		data.WaitForNodes = types.BoolValue(false)
+	}
+	if data.WaitForFulfillment.IsNull() {
+		data.WaitForFulfillment = types.BoolValue(false)
+	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(spotNodePool.ObjectMeta.ResourceVersion))...)
 	tflog.Debug(ctx, "Updating local state", map[string]any{"spec": data})
 	// Save updated data into Terraform state
@@ -244,6 +358,10 @@ func (r *spotnodepoolResource) Read(ctx context.Context, req resource.ReadReques
 }
 
 func (r *spotnodepoolResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var plan, state resource_spotnodepool.SpotnodepoolModel
 
 	// Read Terraform plan data into the model
@@ -256,24 +374,23 @@ func (r *spotnodepoolResource) Update(ctx context.Context, req resource.UpdateRe
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	// TODO: Find the difference between state and plan and update only the changed fields using patch
 	autoscalingSpec, diags := convertAutoscalingValueToSpec(plan.Autoscaling)
 	if diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
 	}
 
+	resp.Diagnostics.Append(r.resolveBidPrice(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	strBidPrice := fmt.Sprintf("%.3f", plan.BidPrice.ValueFloat64())
 	name, err := getNameFromNameOrId(plan.Name.ValueString(), plan.Id.ValueString())
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
-		return
-	}
+	namespace := r.namespace
 
 	// Get the latest version of the resource before we update it
 	// We need to get the latest version to ensure we have the most up-to-date resource version
@@ -334,29 +451,20 @@ func (r *spotnodepoolResource) Update(ctx context.Context, req resource.UpdateRe
 		taints = nil
 	}
 
-	spotNodePool := &ngpcv1.SpotNodePool{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "SpotNodePool",
-			APIVersion: "ngpc.rxt.io/v1",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            name,
-			Namespace:       namespace,
-			ResourceVersion: latest.ResourceVersion,
-		},
-		Spec: ngpcv1.SpotNodePoolSpec{
-			ServerClass:       plan.ServerClass.ValueString(),
-			Desired:           int(plan.DesiredServerCount.ValueInt64()),
-			BidPrice:          strBidPrice,
-			CloudSpace:        plan.CloudspaceName.ValueString(),
-			Autoscaling:       autoscalingSpec,
-			CustomLabels:      labels,
-			CustomAnnotations: annotations,
-			CustomTaints:      taints,
-		},
-	}
-	tflog.Debug(ctx, "Updating spotnodepool", map[string]any{"name": spotNodePool.ObjectMeta.Name})
-	err = r.ngpcClient.Update(ctx, spotNodePool)
+	// Only the fields managed by terraform are changed, the patch leaves the fields
+	// managed by the backend as they are. If the spotnodepool is changed in between,
+	// the fields are applied to its latest version again.
+	tflog.Debug(ctx, "Updating spotnodepool", map[string]any{"name": name})
+	spotNodePool, err := updateObject(ctx, r.ngpcClient, latest, func(pool *ngpcv1.SpotNodePool) {
+		pool.Spec.ServerClass = plan.ServerClass.ValueString()
+		pool.Spec.Desired = int(plan.DesiredServerCount.ValueInt64())
+		pool.Spec.BidPrice = strBidPrice
+		pool.Spec.Autoscaling = autoscalingSpec
+		pool.Spec.CloudSpace = plan.CloudspaceName.ValueString()
+		pool.Spec.CustomLabels = labels
+		pool.Spec.CustomAnnotations = annotations
+		pool.Spec.CustomTaints = taints
+	})
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to update spotnodepool", err.Error())
 		return
@@ -368,11 +476,71 @@ func (r *spotnodepoolResource) Update(ctx context.Context, req resource.UpdateRe
 	}
 	resp.Diagnostics.Append(resp.Private.SetKey(ctx, keyResourceVersion, []byte(spotNodePool.ObjectMeta.ResourceVersion))...)
 	state.LastUpdated = types.StringValue(time.Now().Format(time.RFC3339))
+	state.BidStrategy = plan.BidStrategy
+	state.DeletionProtection = plan.DeletionProtection
+	state.WaitForNodes = plan.WaitForNodes
+	state.WaitForFulfillment = plan.WaitForFulfillment
+	state.Timeouts = plan.Timeouts
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+
+	if plan.WaitForFulfillment.ValueBool() || plan.WaitForNodes.ValueBool() {
+		updateTimeout, diags := plan.Timeouts.Update(ctx, DefaultNodePoolUpdateTimeout)
+		if diags.HasError() {
+			resp.Diagnostics.Append(diags...)
+			return
+		}
+		if plan.WaitForFulfillment.ValueBool() {
+			resp.Diagnostics.Append(r.waitForFulfillment(ctx, name, namespace, &plan, updateTimeout)...)
+		} else {
+			resp.Diagnostics.Append(r.waitForNodes(ctx, name, namespace, plan.CloudspaceName.ValueString(), updateTimeout)...)
+		}
+	}
+}
+
+// waitForFulfillment waits until the bid of the node pool is fulfilled. A lost bid is reported as an error,
+// a timeout as a warning, both along with the market price of the server class and the pending allocations.
+func (r *spotnodepoolResource) waitForFulfillment(ctx context.Context, name string, namespace string,
+	plan *resource_spotnodepool.SpotnodepoolModel, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+	tflog.Info(ctx, "Waiting for the bid of the spotnodepool to be fulfilled", map[string]any{"name": name})
+	err := waitFor(ctx, r.ngpcClient, timeout,
+		waitForSpotNodePoolFulfillment(r.ngpcClient, name, namespace),
+		spotNodePoolScope(name, namespace))
+	if err == nil {
+		return diags
+	}
+	detail := err.Error() +
+		describeMarketPrice(ctx, r.ngpcClient, plan.ServerClass.ValueString(), plan.BidPrice.ValueFloat64()) +
+		describePendingAllocations(ctx, r.ngpcClient, plan.CloudspaceName.ValueString(), namespace, name)
+	if errors.Is(err, errBidLost) {
+		diags.AddError("The bid of the spotnodepool was lost", detail)
+	} else {
+		diags.AddWarning("The bid of the spotnodepool is not fulfilled", detail)
+	}
+	return diags
+}
+
+// waitForNodes waits until the node pool has won its nodes. A timeout is reported as a warning listing
+// the pending allocations of the node pool, the node pool itself is created or updated.
+func (r *spotnodepoolResource) waitForNodes(ctx context.Context, name string, namespace string, cloudspaceName string, timeout time.Duration) diag.Diagnostics {
+	var diags diag.Diagnostics
+	tflog.Info(ctx, "Waiting for the nodes of the spotnodepool", map[string]any{"name": name})
+	err := waitFor(ctx, r.ngpcClient, timeout,
+		waitForSpotNodePoolNodes(r.ngpcClient, name, namespace),
+		spotNodePoolScope(name, namespace))
+	if err != nil {
+		diags.AddWarning("Failed to wait for the nodes of the spotnodepool",
+			err.Error()+describePendingAllocations(ctx, r.ngpcClient, cloudspaceName, namespace, name))
+	}
+	return diags
 }
 
 func (r *spotnodepoolResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !checkProviderConfigured(r.ngpcClient, &resp.Diagnostics) {
+		return
+	}
+
 	var data resource_spotnodepool.SpotnodepoolModel
 	// Read Terraform prior state data into the model
 	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
@@ -385,11 +553,11 @@ func (r *spotnodepoolResource) Delete(ctx context.Context, req resource.DeleteRe
 		resp.Diagnostics.AddError("Failed to get name", err.Error())
 		return
 	}
-	namespace, err := getNamespaceFromEnv()
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to get namespace", err.Error())
+	namespace := r.namespace
+	if !checkDeletionProtection(data.DeletionProtection, "spot node pool", name, &resp.Diagnostics) {
 		return
 	}
+
 	tflog.Info(ctx, "Deleting spotnodepool", map[string]any{"name": name, "namespace": namespace})
 	err = r.ngpcClient.Delete(ctx, &ngpcv1.SpotNodePool{
 		TypeMeta: metav1.TypeMeta{
@@ -412,6 +580,68 @@ func (r *spotnodepoolResource) ImportState(ctx context.Context, req resource.Imp
 	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
 }
 
+// resolveBidPrice sets the bid price of the model from its bid strategy, when it could not
+// be resolved while planning.
+func (r *spotnodepoolResource) resolveBidPrice(ctx context.Context, model *resource_spotnodepool.SpotnodepoolModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if !model.BidPrice.IsUnknown() || model.BidStrategy.IsNull() {
+		return diags
+	}
+	serverClass := &ngpcv1.ServerClass{}
+	err := r.ngpcClient.Get(ctx, ktypes.NamespacedName{Name: model.ServerClass.ValueString()}, serverClass)
+	if err != nil {
+		diags.AddError("Failed to get serverclass", err.Error())
+		return diags
+	}
+	price, err := bidPriceFromStrategy(serverClass, model.BidStrategy)
+	if err != nil {
+		diags.AddError("Failed to resolve the bid strategy", err.Error())
+		return diags
+	}
+	tflog.Debug(ctx, "Resolved the bid strategy", map[string]any{"serverClass": serverClass.Name, "bidPrice": price})
+	model.BidPrice = types.Float64Value(price)
+	return diags
+}
+
+// isBidStrategyKnown reports whether all the values of the bid strategy are known
+func isBidStrategyKnown(strategy resource_spotnodepool.BidStrategyValue) bool {
+	return !strategy.IsUnknown() && !strategy.FractionOfOnDemand.IsUnknown() && !strategy.MarketPlusAbsolute.IsUnknown() &&
+		!strategy.MarketPlusPercent.IsUnknown() && !strategy.MaxBidPrice.IsUnknown()
+}
+
+// bidPriceFromStrategy resolves the bid strategy to a bid price from the current pricing of the server class,
+// capped at the maximum bid price of the strategy.
+func bidPriceFromStrategy(serverClass *ngpcv1.ServerClass, strategy resource_spotnodepool.BidStrategyValue) (float64, error) {
+	var price float64
+	switch {
+	case !strategy.MarketPlusPercent.IsNull():
+		marketPrice, err := marketPricePerHour(serverClass)
+		if err != nil {
+			return 0, err
+		}
+		price = marketPrice * (1 + strategy.MarketPlusPercent.ValueFloat64()/100)
+	case !strategy.MarketPlusAbsolute.IsNull():
+		marketPrice, err := marketPricePerHour(serverClass)
+		if err != nil {
+			return 0, err
+		}
+		price = marketPrice + strategy.MarketPlusAbsolute.ValueFloat64()
+	case !strategy.FractionOfOnDemand.IsNull():
+		onDemandPrice, err := onDemandPricePerHour(serverClass)
+		if err != nil {
+			return 0, err
+		}
+		price = onDemandPrice * strategy.FractionOfOnDemand.ValueFloat64()
+	default:
+		return 0, fmt.Errorf("one of market_plus_percent, market_plus_absolute or fraction_of_on_demand must be set")
+	}
+	price = roundBidPrice(price)
+	if !strategy.MaxBidPrice.IsNull() && price > strategy.MaxBidPrice.ValueFloat64() {
+		price = strategy.MaxBidPrice.ValueFloat64()
+	}
+	return price, nil
+}
+
 // convertAutoscalingValueToSpec converts the autoscaling spec from terraform type to k8s type
 func convertAutoscalingValueToSpec(
 	autoscalingValue resource_spotnodepool.AutoscalingValue) (ngpcv1.AutoscalingSpec, diag.Diagnostics) {
@@ -483,6 +713,8 @@ func setSpotnodepoolState(ctx context.Context, spotnodepool *ngpcv1.SpotNodePool
 	}
 
 	state.BidPrice = types.Float64Value(floatBidPrice)
+	state.EstimatedHourlyCost, state.EstimatedMonthlyCost = estimatedCosts(
+		spotNodePoolHourlyCost(floatBidPrice, spotnodepool.Spec.Desired, autoscalingSpec))
 	state.BidStatus = types.StringValue(spotnodepool.Status.BidStatus)
 	if spotnodepool.Status.WonCount != nil {
 		state.WonCount = types.Int64Value(int64(*spotnodepool.Status.WonCount))
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/rackerlabs/terraform-provider-spot/internal/provider/resource_spotnodepool"
+)
+
+func TestBidPriceFromStrategy(t *testing.T) {
+	null := types.Float64Null()
+	tests := []struct {
+		name        string
+		marketPrice string
+		strategy    resource_spotnodepool.BidStrategyValue
+		want        float64
+		wantErr     bool
+	}{
+		{
+			name:        "market plus percent",
+			marketPrice: "$0.010",
+			strategy:    resource_spotnodepool.BidStrategyValue{MarketPlusPercent: types.Float64Value(25), MarketPlusAbsolute: null, FractionOfOnDemand: null, MaxBidPrice: null},
+			want:        0.013,
+		},
+		{
+			name:        "market plus absolute",
+			marketPrice: "0.010",
+			strategy:    resource_spotnodepool.BidStrategyValue{MarketPlusPercent: null, MarketPlusAbsolute: types.Float64Value(0.002), FractionOfOnDemand: null, MaxBidPrice: null},
+			want:        0.012,
+		},
+		{
+			name:     "fraction of on-demand",
+			strategy: resource_spotnodepool.BidStrategyValue{MarketPlusPercent: null, MarketPlusAbsolute: null, FractionOfOnDemand: types.Float64Value(0.5), MaxBidPrice: null},
+			want:     0.1,
+		},
+		{
+			name:        "capped at the max bid price",
+			marketPrice: "0.010",
+			strategy:    resource_spotnodepool.BidStrategyValue{MarketPlusPercent: types.Float64Value(100), MarketPlusAbsolute: null, FractionOfOnDemand: null, MaxBidPrice: types.Float64Value(0.015)},
+			want:        0.015,
+		},
+		{
+			name:        "below the max bid price",
+			marketPrice: "0.010",
+			strategy:    resource_spotnodepool.BidStrategyValue{MarketPlusPercent: types.Float64Value(10), MarketPlusAbsolute: null, FractionOfOnDemand: null, MaxBidPrice: types.Float64Value(0.015)},
+			want:        0.011,
+		},
+		{
+			name:        "market price unavailable",
+			marketPrice: "",
+			strategy:    resource_spotnodepool.BidStrategyValue{MarketPlusPercent: types.Float64Value(10), MarketPlusAbsolute: null, FractionOfOnDemand: null, MaxBidPrice: null},
+			wantErr:     true,
+		},
+		{
+			name:     "no strategy set",
+			strategy: resource_spotnodepool.BidStrategyValue{MarketPlusPercent: null, MarketPlusAbsolute: null, FractionOfOnDemand: null, MaxBidPrice: null},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bidPriceFromStrategy(testServerClass(tt.marketPrice, "$146", "month"), tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bidPriceFromStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("bidPriceFromStrategy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
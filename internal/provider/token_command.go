@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultTokenCommandTimeout is the default timeout for the token_command to finish.
+	DefaultTokenCommandTimeout = 30 * time.Second
+)
+
+// tokenCommandOutput is the JSON document the token_command is expected to write to stdout.
+// Either access_token or refresh_token must be set.
+type tokenCommandOutput struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+var _ tokenMinter = (*commandTokenMinter)(nil)
+
+// commandTokenMinter obtains the token by running an external credential helper,
+// for example a wrapper around the vault or 1Password CLI.
+type commandTokenMinter struct {
+	command []string
+	timeout time.Duration
+	// config is used to exchange the refresh token returned by the command
+	config *oauth2.Config
+}
+
+func (m *commandTokenMinter) mintToken(logCtx context.Context) (*oauth2.Token, error) {
+	// The output of the command is a secret, it must never be logged
+	tflog.Debug(logCtx, "Running token command")
+	output, err := runTokenCommand(m.command, m.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if output.AccessToken != "" {
+		return &oauth2.Token{AccessToken: output.AccessToken, Expiry: output.ExpiresAt}, nil
+	}
+	if output.RefreshToken == "" {
+		return nil, errors.New("output of token command contains neither access_token nor refresh_token")
+	}
+	// The context is not tied to a request, because the token source outlives the provider Configure call
+	idToken, _, err := GetAccessToken(m.config.TokenSource(context.Background(), &oauth2.Token{RefreshToken: output.RefreshToken}))
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{AccessToken: idToken, Expiry: output.ExpiresAt}, nil
+}
+
+// runTokenCommand runs the command and parses its stdout. Neither stdout nor stderr
+// of the command is included in the returned errors, since they may contain secrets.
+func runTokenCommand(command []string, timeout time.Duration) (*tokenCommandOutput, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, errors.New("token command is empty")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("token command %s did not finish within %s", command[0], timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("token command %s failed with exit code %d", command[0], exitErr.ExitCode())
+		}
+		return nil, fmt.Errorf("failed to run token command %s: %w", command[0], err)
+	}
+
+	var output tokenCommandOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		// Do not wrap the error, json syntax errors can quote parts of the output
+		return nil, fmt.Errorf("output of token command %s is not a valid JSON document", command[0])
+	}
+	return &output, nil
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxTokenFileSize is the maximum number of bytes read from the token file
+	maxTokenFileSize = 5120
+	// tokenFileLockTimeout is the maximum time to wait for the lock on the token file
+	tokenFileLockTimeout = 30 * time.Second
+	// tokenFileLockStaleAfter is the age after which a lock is considered to be left behind by a crashed process
+	tokenFileLockStaleAfter = 2 * time.Minute
+	// tokenFileLockRetryInterval is the interval at which the lock is retried
+	tokenFileLockRetryInterval = 100 * time.Millisecond
+)
+
+// lockTokenFile acquires an advisory lock on the token file, so that processes sharing
+// the token file do not rotate the refresh token concurrently.
+// It returns a function that releases the lock.
+func lockTokenFile(filename string) (func(), error) {
+	lockFile := filename + ".lock"
+	deadline := time.Now().Add(tokenFileLockTimeout)
+	for {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockFile) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", lockFile, err)
+		}
+		if info, statErr := os.Stat(lockFile); statErr == nil && time.Since(info.ModTime()) > tokenFileLockStaleAfter {
+			os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock file %s", lockFile)
+		}
+		time.Sleep(tokenFileLockRetryInterval)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over filename,
+// so that readers never see a partially written file.
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmpFile.Name()
+	// Remove the temporary file if anything goes wrong, it is a no-op after rename
+	defer os.Remove(tmpName)
+
+	if err := tmpFile.Chmod(perm); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
@@ -0,0 +1,182 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestLockTokenFile(t *testing.T) {
+	t.Run("exclusive", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		unlock, err := lockTokenFile(tokenFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		acquired := make(chan func())
+		go func() {
+			unlock, err := lockTokenFile(tokenFile)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+				close(acquired)
+				return
+			}
+			acquired <- unlock
+		}()
+		select {
+		case <-acquired:
+			t.Fatal("the lock was acquired twice")
+		case <-time.After(3 * tokenFileLockRetryInterval):
+		}
+		unlock()
+		select {
+		case unlock := <-acquired:
+			if unlock != nil {
+				unlock()
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the lock was not acquired after it was released")
+		}
+		if _, err := os.Stat(tokenFile + ".lock"); !os.IsNotExist(err) {
+			t.Errorf("the lock file was not removed: %v", err)
+		}
+	})
+
+	t.Run("stale lock is taken over", func(t *testing.T) {
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(tokenFile+".lock", nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		stale := time.Now().Add(-tokenFileLockStaleAfter - time.Minute)
+		if err := os.Chtimes(tokenFile+".lock", stale, stale); err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		unlock, err := lockTokenFile(tokenFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer unlock()
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("took %s to take over the stale lock", elapsed)
+		}
+		info, err := os.Stat(tokenFile + ".lock")
+		if err != nil {
+			t.Fatalf("the lock file was not created: %v", err)
+		}
+		if !info.ModTime().After(stale) {
+			t.Error("the stale lock file was not replaced")
+		}
+	})
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("old-refresh-token"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(tokenFile, []byte("new-refresh-token"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new-refresh-token" {
+		t.Errorf("got %q, want the new refresh token", data)
+	}
+	info, err := os.Stat(tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("got mode %s, want -rw-------", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got files %v, want the token file only", entries)
+	}
+
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "token"), []byte("new-refresh-token"), 0600); err == nil {
+		t.Error("expected an error writing to a missing directory")
+	}
+}
+
+// refreshTokenServer returns a token endpoint rotating the refresh token, which records the refresh tokens it receives
+func refreshTokenServer(t *testing.T, received *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse the token request: %v", err)
+		}
+		*received = append(*received, r.PostForm.Get("refresh_token"))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-token",
+			"id_token":      "id-token",
+			"refresh_token": "rotated-refresh-token",
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRefreshTokenMinterTokenFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		fileToken    string
+		wantReceived string
+	}{
+		{"rotated refresh token is persisted", "refresh-token", "refresh-token"},
+		{"refresh token rotated by another process is used", "refresh-token-of-another-process", "refresh-token-of-another-process"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tokenFile := filepath.Join(dir, "token")
+			if err := os.WriteFile(tokenFile, []byte(tt.fileToken), 0600); err != nil {
+				t.Fatal(err)
+			}
+			var received []string
+			server := refreshTokenServer(t, &received)
+			minter := &refreshTokenMinter{
+				config:       &oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: server.URL}},
+				refreshToken: "refresh-token",
+				tokenFile:    tokenFile,
+			}
+			token, err := minter.mintToken(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.AccessToken != "id-token" {
+				t.Errorf("got access token %q, want the id token", token.AccessToken)
+			}
+			if len(received) != 1 || received[0] != tt.wantReceived {
+				t.Errorf("the token endpoint received %v, want [%s]", received, tt.wantReceived)
+			}
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "rotated-refresh-token" || minter.refreshToken != "rotated-refresh-token" {
+				t.Errorf("got refresh token %q in the file and %q in memory, want the rotated one", data, minter.refreshToken)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("got files %v, want the token file only", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// tokenExpiryDelta is how long before its expiry the token is considered expired and re-minted.
+	tokenExpiryDelta = 2 * time.Minute
+)
+
+// tokenMinter exchanges the configured credentials for a new Spot token (JWT).
+// The Expiry of the returned token is optional, the expiry of the JWT is used if it is not set.
+type tokenMinter interface {
+	mintToken(logCtx context.Context) (*oauth2.Token, error)
+}
+
+var _ oauth2.TokenSource = (*spotTokenSource)(nil)
+
+// spotTokenSource mints tokens using the tokenMinter and caches them until
+// shortly before they expire. The returned oauth2.Token carries the
+// minted JWT as access token since that is what the Spot backend expects.
+type spotTokenSource struct {
+	mu     sync.Mutex
+	minter tokenMinter
+	token  *oauth2.Token
+	// logCtx is only used for logging, refreshes are not bound to its lifetime
+	logCtx context.Context
+}
+
+func newSpotTokenSource(logCtx context.Context, minter tokenMinter) *spotTokenSource {
+	return &spotTokenSource{
+		minter: minter,
+		logCtx: logCtx,
+	}
+}
+
+// Token returns the cached token if it is still valid, otherwise mints a new one.
+func (s *spotTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != nil && time.Now().Add(tokenExpiryDelta).Before(s.token.Expiry) {
+		return s.token, nil
+	}
+	return s.refreshLocked()
+}
+
+// Invalidate drops the cached token so that the next call to Token mints a new one.
+func (s *spotTokenSource) Invalidate(rejected *oauth2.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Another request may have already refreshed the token
+	if s.token != nil && rejected != nil && s.token.AccessToken != rejected.AccessToken {
+		return
+	}
+	s.token = nil
+}
+
+func (s *spotTokenSource) refreshLocked() (*oauth2.Token, error) {
+	tflog.Debug(s.logCtx, "Refreshing access token")
+	mintedToken, err := s.minter.mintToken(s.logCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	rxtSpotToken := NewRxtSpotToken(mintedToken.AccessToken)
+	if err := rxtSpotToken.Parse(); err != nil {
+		return nil, fmt.Errorf("failed to parse refreshed token: %w", err)
+	}
+	exp, err := rxtSpotToken.claims.GetExpirationTime()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiration time: %w", err)
+	}
+	expiry := exp.Time
+	if !mintedToken.Expiry.IsZero() && mintedToken.Expiry.Before(expiry) {
+		expiry = mintedToken.Expiry
+	}
+	s.token = &oauth2.Token{
+		AccessToken: mintedToken.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      expiry,
+	}
+	tflog.Debug(s.logCtx, "Refreshed access token", map[string]any{"expiry": expiry.UTC().Format(time.RFC3339)})
+	return s.token, nil
+}
+
+var _ tokenMinter = (*refreshTokenMinter)(nil)
+
+// refreshTokenMinter mints id_tokens by exchanging the user refresh token
+type refreshTokenMinter struct {
+	config       *oauth2.Config
+	refreshToken string
+	// tokenFile is set when rotated refresh tokens should be persisted back to the file
+	tokenFile string
+}
+
+func (m *refreshTokenMinter) mintToken(logCtx context.Context) (*oauth2.Token, error) {
+	if m.tokenFile != "" {
+		unlock, err := lockTokenFile(m.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock token file: %w", err)
+		}
+		defer unlock()
+		// Another process sharing the token file may have rotated the refresh token already
+		fileToken, err := readFileUpToNBytes(m.tokenFile, maxTokenFileSize)
+		if err == nil && fileToken != "" && fileToken != m.refreshToken {
+			tflog.Debug(logCtx, "Using refresh token rotated by another process", map[string]any{"tokenFile": m.tokenFile})
+			m.refreshToken = fileToken
+		}
+	}
+	// The context is not tied to a request, because the token source outlives the provider Configure call
+	idToken, refreshToken, err := GetAccessToken(m.config.TokenSource(context.Background(), &oauth2.Token{RefreshToken: m.refreshToken}))
+	if err != nil {
+		return nil, err
+	}
+	if refreshToken != "" && refreshToken != m.refreshToken {
+		tflog.Debug(logCtx, "Refresh token is rotated")
+		m.refreshToken = refreshToken
+		if m.tokenFile != "" {
+			// The new refresh token is still usable in memory, hence failing to persist it is not fatal
+			if err := writeFileAtomic(m.tokenFile, []byte(refreshToken), 0600); err != nil {
+				tflog.Warn(logCtx, "Failed to persist rotated refresh token", map[string]any{"tokenFile": m.tokenFile, "error": err.Error()})
+			} else {
+				tflog.Debug(logCtx, "Persisted rotated refresh token", map[string]any{"tokenFile": m.tokenFile})
+			}
+		}
+	}
+	return &oauth2.Token{AccessToken: idToken}, nil
+}
+
+var _ tokenMinter = (*clientCredentialsMinter)(nil)
+
+// clientCredentialsMinter mints access tokens for machine to machine applications
+// using the OAuth2 client credentials grant
+type clientCredentialsMinter struct {
+	config *clientcredentials.Config
+}
+
+func (m *clientCredentialsMinter) mintToken(logCtx context.Context) (*oauth2.Token, error) {
+	token, err := m.config.Token(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{AccessToken: token.AccessToken, Expiry: token.Expiry}, nil
+}
+
+// tokenRefreshTransport authenticates requests using the token source and
+// retries a request once with a freshly minted token if the server returns 401.
+type tokenRefreshTransport struct {
+	source *spotTokenSource
+	base   http.RoundTripper
+}
+
+// wrapTransportWithTokenSource returns a function to be used as transport wrapper of the ngpc client config
+func wrapTransportWithTokenSource(source *spotTokenSource) func(rt http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &tokenRefreshTransport{source: source, base: rt}
+	}
+}
+
+func (t *tokenRefreshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(authorizedRequest(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// Request body can be replayed only if GetBody is set
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	tflog.Debug(req.Context(), "Received 401 from Spot backend, retrying with a refreshed access token",
+		map[string]any{"method": req.Method, "url": req.URL.String()})
+	t.source.Invalidate(token)
+	token, err = t.source.Token()
+	if err != nil {
+		// Return the original 401 response, the refresh error is only logged
+		tflog.Debug(req.Context(), "Failed to refresh access token", map[string]any{"error": err.Error()})
+		return resp, nil
+	}
+	retryReq := authorizedRequest(req, token)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retryReq.Body = body
+	}
+	resp.Body.Close()
+	return t.base.RoundTrip(retryReq)
+}
+
+// authorizedRequest returns a clone of the request with the Authorization header set,
+// RoundTripper must not modify the original request.
+func authorizedRequest(req *http.Request, token *oauth2.Token) *http.Request {
+	clone := req.Clone(req.Context())
+	token.SetAuthHeader(clone)
+	return clone
+}
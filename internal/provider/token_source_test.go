@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// sequenceMinter mints the tokens in order, the last one again once all are minted
+type sequenceMinter struct {
+	tokens []string
+	minted int
+}
+
+func (m *sequenceMinter) mintToken(logCtx context.Context) (*oauth2.Token, error) {
+	token := m.tokens[min(m.minted, len(m.tokens)-1)]
+	m.minted++
+	return &oauth2.Token{AccessToken: token}, nil
+}
+
+// testJWT returns a signed JWT expiring in an hour, only its claims are read by the token source
+func testJWT(t *testing.T, subject string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": subject,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestTokenRefreshTransport(t *testing.T) {
+	rejected, accepted := testJWT(t, "rejected"), testJWT(t, "accepted")
+
+	tests := []struct {
+		name         string
+		tokens       []string
+		body         string
+		replayable   bool
+		wantStatus   int
+		wantRequests int
+		wantMinted   int
+	}{
+		{"retried with a refreshed token", []string{rejected, accepted}, "", false, http.StatusOK, 2, 2},
+		{"retried with the request body", []string{rejected, accepted}, `{"spec":{}}`, true, http.StatusOK, 2, 2},
+		{"not retried if the body can not be replayed", []string{rejected, accepted}, `{"spec":{}}`, false, http.StatusUnauthorized, 1, 1},
+		{"retried once", []string{rejected}, "", false, http.StatusUnauthorized, 2, 2},
+		{"not retried if accepted", []string{accepted}, "", false, http.StatusOK, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != tt.body {
+					t.Errorf("got body %q, want %q", body, tt.body)
+				}
+				if r.Header.Get("Authorization") != "Bearer "+accepted {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer server.Close()
+
+			minter := &sequenceMinter{tokens: tt.tokens}
+			transport := wrapTransportWithTokenSource(newSpotTokenSource(context.Background(), minter))(http.DefaultTransport)
+			var body io.Reader
+			if tt.body != "" {
+				body = bytes.NewBufferString(tt.body)
+				if !tt.replayable {
+					// A reader of unknown type leaves GetBody unset
+					body = io.MultiReader(body)
+				}
+			}
+			req, err := http.NewRequest(http.MethodPost, server.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", requests, tt.wantRequests)
+			}
+			if minter.minted != tt.wantMinted {
+				t.Errorf("got %d minted tokens, want %d", minter.minted, tt.wantMinted)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Error("the original request was modified")
+			}
+		})
+	}
+}
+
+func TestSpotTokenSourceCachesToken(t *testing.T) {
+	minter := &sequenceMinter{tokens: []string{testJWT(t, "first"), testJWT(t, "second")}}
+	source := newSpotTokenSource(context.Background(), minter)
+	for i := 0; i < 3; i++ {
+		if _, err := source.Token(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if minter.minted != 1 {
+		t.Errorf("got %d minted tokens, want 1", minter.minted)
+	}
+	first, _ := source.Token()
+	source.Invalidate(&oauth2.Token{AccessToken: fmt.Sprintf("%s-stale", first.AccessToken)})
+	if token, _ := source.Token(); token.AccessToken != first.AccessToken {
+		t.Error("the token was dropped although another one was rejected")
+	}
+	source.Invalidate(first)
+	if token, _ := source.Token(); token.AccessToken == first.AccessToken {
+		t.Error("the rejected token was not dropped")
+	}
+}
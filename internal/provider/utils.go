@@ -3,7 +3,6 @@ package provider
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
 	"github.com/google/uuid"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
 func generateRandomUUID() (string, error) {
@@ -26,6 +26,22 @@ func generateRandomUUID() (string, error) {
 	return "", err
 }
 
+// newNodePoolName returns the name of a node pool being created: the configured name, or the name
+// prefix followed by 8 random characters, or a random UUID if neither is set.
+func newNodePoolName(name, namePrefix basetypes.StringValue) (string, error) {
+	if !name.IsNull() && !name.IsUnknown() && name.ValueString() != "" {
+		return name.ValueString(), nil
+	}
+	randomUUID, err := generateRandomUUID()
+	if err != nil {
+		return "", err
+	}
+	if !namePrefix.IsNull() && !namePrefix.IsUnknown() && namePrefix.ValueString() != "" {
+		return namePrefix.ValueString() + randomUUID[:8], nil
+	}
+	return randomUUID, nil
+}
+
 // getNameFromId returns name from id of the resource or data source stored in a state
 // id format: namespace/name, this function ignores namespace.
 func getNameFromId(id string) (string, error) {
@@ -46,13 +62,30 @@ func getNameFromNameOrId(name, id string) (string, error) {
 	return getNameFromId(id)
 }
 
-func getNamespaceFromEnv() (string, error) {
-	// TODO: Find a better way to get value of the namespace from provider.go
-	namespace := os.Getenv("RXTSPOT_ORG_NS")
-	if namespace == "" {
-		return "", errors.New("RXTSPOT_ORG_NS is not set")
+// stringValueOrEnv returns the value of the attribute if it is set,
+// otherwise the value of the environment variable.
+func stringValueOrEnv(val basetypes.StringValue, envVar string) string {
+	if !val.IsNull() && !val.IsUnknown() && val.ValueString() != "" {
+		return val.ValueString()
 	}
-	return namespace, nil
+	return os.Getenv(envVar)
+}
+
+// boolValueOrEnv returns the value of the attribute if it is set,
+// otherwise the value of the environment variable parsed as bool.
+func boolValueOrEnv(val basetypes.BoolValue, envVar string) (bool, error) {
+	if !val.IsNull() && !val.IsUnknown() {
+		return val.ValueBool(), nil
+	}
+	envVal := os.Getenv(envVar)
+	if envVal == "" {
+		return false, nil
+	}
+	boolVal, err := strconv.ParseBool(envVal)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q of %s environment variable: %w", envVal, envVar, err)
+	}
+	return boolVal, nil
 }
 
 func findNamespaceFromID(orgID string) string {
@@ -94,6 +127,40 @@ func FindOrgName(ctx context.Context, client *ngpc.OrganizerClient, userJWT stri
 	return "", fmt.Errorf("organization %s not found", orgID)
 }
 
+// resolveOrgID returns the ID of the organization the user belongs to
+// whose ID or display name matches the given organization.
+func resolveOrgID(ctx context.Context, client *ngpc.OrganizerClient, userJWT string, organization string) (string, error) {
+	orgList, err := client.ListOrganizationsForUser(ctx, userJWT)
+	if err != nil {
+		return "", err
+	}
+	if orgList == nil {
+		return "", fmt.Errorf("organization %s not found", organization)
+	}
+	var matchedIDs, available []string
+	for _, org := range orgList.Organizations {
+		if org.ID == nil {
+			continue
+		}
+		if *org.ID == organization {
+			return *org.ID, nil
+		}
+		if org.GetDisplayName() == organization {
+			matchedIDs = append(matchedIDs, *org.ID)
+		}
+		available = append(available, fmt.Sprintf("%s (%s)", org.GetDisplayName(), *org.ID))
+	}
+	switch len(matchedIDs) {
+	case 0:
+		return "", fmt.Errorf("organization %s not found, the user belongs to: %s", organization, strings.Join(available, ", "))
+	case 1:
+		return matchedIDs[0], nil
+	default:
+		return "", fmt.Errorf("display name %s matches multiple organizations %s, use the organization ID instead",
+			organization, strings.Join(matchedIDs, ", "))
+	}
+}
+
 func StrSliceContains(slice []string, val string) bool {
 	for _, item := range slice {
 		if item == val {
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// verificationKeySource provides the public keys used to verify the signature of the token.
+// String describes the source and is included in verification errors.
+type verificationKeySource interface {
+	publicKeys(ctx context.Context, kid string) ([]crypto.PublicKey, error)
+	String() string
+}
+
+var _ verificationKeySource = (*issuerKeySource)(nil)
+
+// issuerKeySource fetches the keys from the JWKS URL of the issuer
+type issuerKeySource struct {
+	jwksURL string
+}
+
+func (s *issuerKeySource) publicKeys(ctx context.Context, kid string) ([]crypto.PublicKey, error) {
+	key, err := defaultJWKSCache.publicKey(ctx, s.jwksURL, kid)
+	if err != nil {
+		return nil, err
+	}
+	return []crypto.PublicKey{key}, nil
+}
+
+func (s *issuerKeySource) String() string {
+	return fmt.Sprintf("keys fetched from issuer JWKS %s", s.jwksURL)
+}
+
+var _ verificationKeySource = (*pinnedKeySource)(nil)
+
+// pinnedKeySource holds keys configured in the provider for offline verification.
+// Keys read from a JWKS file are looked up by kid, PEM keys carry no kid and are all tried.
+type pinnedKeySource struct {
+	keysByKid   map[string]crypto.PublicKey
+	keys        []crypto.PublicKey
+	description string
+}
+
+func (s *pinnedKeySource) publicKeys(ctx context.Context, kid string) ([]crypto.PublicKey, error) {
+	if s.keysByKid == nil {
+		return s.keys, nil
+	}
+	if key, found := s.keysByKid[kid]; found {
+		return []crypto.PublicKey{key}, nil
+	}
+	return nil, fmt.Errorf("%w: kid %s", ErrKeyNotFound, kid)
+}
+
+func (s *pinnedKeySource) String() string {
+	return s.description
+}
+
+// newJWKSFileKeySource reads the keys from a local JWKS file
+func newJWKSFileKeySource(ctx context.Context, filename string) (*pinnedKeySource, error) {
+	data, err := readFileUpToNBytes(filename, maxJWKSSize)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(ctx, []byte(data))
+	if err != nil {
+		return nil, err
+	}
+	return &pinnedKeySource{
+		keysByKid:   keys,
+		description: fmt.Sprintf("keys pinned in JWKS file %s", filename),
+	}, nil
+}
+
+// newPEMKeySource parses PEM encoded public keys or certificates
+func newPEMKeySource(data string) (*pinnedKeySource, error) {
+	var keys []crypto.PublicKey
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		key, err := parsePEMPublicKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM encoded public key found")
+	}
+	return &pinnedKeySource{
+		keys:        keys,
+		description: fmt.Sprintf("%d pinned PEM public key(s)", len(keys)),
+	}, nil
+}
+
+func parsePEMPublicKey(block *pem.Block) (crypto.PublicKey, error) {
+	var key crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing PEM %s: %w", block.Type, err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T, only RSA and EC keys are supported", key)
+	}
+}
@@ -0,0 +1,233 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ktypes "k8s.io/apimachinery/pkg/types"
+)
+
+// spotNodePoolRequiredNodes returns the number of nodes a spot node pool waits for,
+// which is the minimum number of nodes when autoscaling is enabled.
+func spotNodePoolRequiredNodes(pool *ngpcv1.SpotNodePool) int {
+	if pool.Spec.Autoscaling.Enabled {
+		return pool.Spec.Autoscaling.MinNodes
+	}
+	return pool.Spec.Desired
+}
+
+// waitForSpotNodePoolNodes returns retry function that waits until the spot node pool
+// has won its desired or minimum number of nodes.
+func waitForSpotNodePoolNodes(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		pool, err := getSpotNodePool(ctx, ngpcClient, name, namespace)
+		if err != nil {
+			return err
+		}
+		return checkSpotNodePoolNodes(ctx, pool, startTime)
+	}
+}
+
+// getSpotNodePool gets the spot node pool for a wait, failing the wait if it can not be read
+func getSpotNodePool(ctx context.Context, ngpcClient ngpc.Client, name string, namespace string) (*ngpcv1.SpotNodePool, error) {
+	pool := &ngpcv1.SpotNodePool{}
+	err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, pool)
+	if err != nil {
+		return nil, backoff.Permanent(fmt.Errorf("failed to get spotnodepool: %w", err))
+	}
+	return pool, nil
+}
+
+// checkSpotNodePoolNodes returns an error until the spot node pool has won its desired or minimum number of nodes
+func checkSpotNodePoolNodes(ctx context.Context, pool *ngpcv1.SpotNodePool, startTime time.Time) error {
+	required := spotNodePoolRequiredNodes(pool)
+	won := 0
+	if pool.Status.WonCount != nil {
+		won = *pool.Status.WonCount
+	}
+	tflog.Debug(ctx, "Spotnodepool nodes status", map[string]any{
+		"name":      pool.Name,
+		"won":       won,
+		"required":  required,
+		"bidStatus": pool.Status.BidStatus,
+		"age":       time.Since(startTime).String(),
+	})
+	if won >= required {
+		return nil
+	}
+	return fmt.Errorf("spotnodepool %s has won %d of %d nodes after %s (bid status: %s)",
+		pool.Name, won, required, time.Since(startTime).Round(time.Second), pool.Status.BidStatus)
+}
+
+// errBidLost is wrapped by the errors of waitForSpotNodePoolFulfillment when the bid is lost,
+// waiting longer does not help then.
+var errBidLost = errors.New("bid lost")
+
+// bidStatusLost is the bid status of a spot node pool whose bid was lost.
+const bidStatusLost = "Lost"
+
+// isBidLost reports whether the bid status of a spot node pool says the bid was lost.
+func isBidLost(bidStatus string) bool {
+	return strings.EqualFold(strings.TrimSpace(bidStatus), bidStatusLost)
+}
+
+// waitForSpotNodePoolFulfillment returns retry function that waits until the bid of the spot node pool
+// is fulfilled, which is when its desired or minimum number of nodes are won. It stops with a permanent
+// error wrapping errBidLost if the bid is lost.
+func waitForSpotNodePoolFulfillment(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		pool, err := getSpotNodePool(ctx, ngpcClient, name, namespace)
+		if err != nil {
+			return err
+		}
+		err = checkSpotNodePoolNodes(ctx, pool, startTime)
+		if err != nil && isBidLost(pool.Status.BidStatus) {
+			return backoff.Permanent(fmt.Errorf("%w: spotnodepool %s bid status is %s", errBidLost, name, pool.Status.BidStatus))
+		}
+		return err
+	}
+}
+
+// describeMarketPrice compares the bid price with the current market price of the server class,
+// to be appended to the error of a wait for a bid. It returns an empty string if the price is not known.
+func describeMarketPrice(ctx context.Context, ngpcClient ngpc.Client, serverClassName string, bidPrice float64) string {
+	serverClass := &ngpcv1.ServerClass{}
+	err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: serverClassName}, serverClass)
+	if err != nil {
+		tflog.Warn(ctx, "Failed to get the market price of the serverclass", map[string]any{"name": serverClassName, "error": err.Error()})
+		return ""
+	}
+	marketPrice := serverClass.Status.SpotPricing.MarketPricePerHour
+	if marketPrice == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThe current market price of serverclass %s is %s per hour, the bid price is %.3f per hour.",
+		serverClassName, marketPrice, bidPrice)
+}
+
+// waitForOnDemandNodePoolNodes returns retry function that waits until the desired
+// nodes of the on-demand node pool are reserved.
+func waitForOnDemandNodePoolNodes(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		pool := &ngpcv1.OnDemandNodePool{}
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, pool)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to get ondemandnodepool: %w", err))
+		}
+		reserved := 0
+		if pool.Status.ReservedCount != nil {
+			reserved = *pool.Status.ReservedCount
+		}
+		tflog.Debug(ctx, "Ondemandnodepool nodes status", map[string]any{
+			"name":           name,
+			"reserved":       reserved,
+			"desired":        pool.Spec.Desired,
+			"reservedStatus": pool.Status.ReservedStatus,
+			"age":            time.Since(startTime).String(),
+		})
+		if reserved >= pool.Spec.Desired {
+			return nil
+		}
+		return fmt.Errorf("ondemandnodepool %s has reserved %d of %d nodes after %s (status: %s)",
+			name, reserved, pool.Spec.Desired, time.Since(startTime).Round(time.Second), pool.Status.ReservedStatus)
+	}
+}
+
+// waitForCloudSpaceNodes returns retry function that waits until the node pools listed in
+// the cloudspace have their nodes, the won counts of the spot node pools are taken from the bids
+// in the status of the cloudspace.
+func waitForCloudSpaceNodes(ngpcClient ngpc.Client, name string, namespace string) waitCondition {
+	startTime := time.Now()
+
+	return func(ctx context.Context) error {
+		cloudspace := &ngpcv1.CloudSpace{}
+		err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, cloudspace)
+		if err != nil {
+			return backoff.Permanent(fmt.Errorf("failed to get cloudspace: %w", err))
+		}
+		wonCounts := make(map[string]int, len(cloudspace.Status.Bids))
+		for _, bid := range cloudspace.Status.Bids {
+			if bid.WonCount != nil {
+				wonCounts[bid.BidName] = *bid.WonCount
+			}
+		}
+		var waiting []string
+		for _, poolName := range cloudspace.Spec.BidRequests {
+			pool := &ngpcv1.SpotNodePool{}
+			err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: poolName, Namespace: namespace}, pool)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("failed to get spotnodepool %s: %w", poolName, err))
+			}
+			if required := spotNodePoolRequiredNodes(pool); wonCounts[poolName] < required {
+				waiting = append(waiting, fmt.Sprintf("spotnodepool %s won %d of %d", poolName, wonCounts[poolName], required))
+			}
+		}
+		for _, poolName := range cloudspace.Spec.OnDemandRequests {
+			pool := &ngpcv1.OnDemandNodePool{}
+			err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: poolName, Namespace: namespace}, pool)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return backoff.Permanent(fmt.Errorf("failed to get ondemandnodepool %s: %w", poolName, err))
+			}
+			reserved := 0
+			if pool.Status.ReservedCount != nil {
+				reserved = *pool.Status.ReservedCount
+			}
+			if reserved < pool.Spec.Desired {
+				waiting = append(waiting, fmt.Sprintf("ondemandnodepool %s reserved %d of %d", poolName, reserved, pool.Spec.Desired))
+			}
+		}
+		tflog.Debug(ctx, "Cloudspace nodes status", map[string]any{
+			"name":    name,
+			"phase":   cloudspace.Status.Phase,
+			"waiting": waiting,
+			"age":     time.Since(startTime).String(),
+		})
+		if len(waiting) == 0 {
+			return nil
+		}
+		return fmt.Errorf("cloudspace %s is waiting for nodes after %s (phase: %s): %s",
+			name, time.Since(startTime).Round(time.Second), cloudspace.Status.Phase, strings.Join(waiting, ", "))
+	}
+}
+
+// describePendingAllocations lists the pending allocations of the cloudspace, limited to the given bids
+// if any, to be appended to the error of a wait for nodes. It returns an empty string if there are none.
+func describePendingAllocations(ctx context.Context, ngpcClient ngpc.Client, name string, namespace string, bidNames ...string) string {
+	cloudspace := &ngpcv1.CloudSpace{}
+	err := ngpcClient.Get(ctx, ktypes.NamespacedName{Name: name, Namespace: namespace}, cloudspace)
+	if err != nil {
+		tflog.Warn(ctx, "Failed to get the pending allocations of the cloudspace", map[string]any{"name": name, "error": err.Error()})
+		return ""
+	}
+	var allocations []string
+	for _, allocation := range cloudspace.Status.PendingAllocations {
+		if len(bidNames) > 0 && !StrSliceContains(bidNames, allocation.BidName) {
+			continue
+		}
+		allocations = append(allocations, fmt.Sprintf("- bid %s: %d x %s", allocation.BidName, allocation.Count, allocation.ServerClassName))
+	}
+	if len(allocations) == 0 {
+		return ""
+	}
+	return "\n\nPending allocations:\n" + strings.Join(allocations, "\n")
+}
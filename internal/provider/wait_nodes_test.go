@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/cenkalti/backoff/v4"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+)
+
+func TestIsBidLost(t *testing.T) {
+	tests := []struct {
+		bidStatus string
+		want      bool
+	}{
+		{"Lost", true},
+		{"lost", true},
+		{" Lost ", true},
+		{"Won", false},
+		{"Pending", false},
+		{"not lost", false},
+		{"lost-recovered", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isBidLost(tt.bidStatus); got != tt.want {
+			t.Errorf("isBidLost(%q) = %v, want %v", tt.bidStatus, got, tt.want)
+		}
+	}
+}
+
+// spotNodePoolClient returns copies of the spot node pool and counts the calls
+type spotNodePoolClient struct {
+	ngpc.Client
+	pool ngpcv1.SpotNodePool
+	gets int
+}
+
+func (c *spotNodePoolClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	c.gets++
+	*obj.(*ngpcv1.SpotNodePool) = c.pool
+	return nil
+}
+
+func TestWaitForSpotNodePoolFulfillment(t *testing.T) {
+	tests := []struct {
+		name          string
+		won           int
+		bidStatus     string
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{"fulfilled", 3, "Won", false, false},
+		{"fulfilled although lost since", 3, "Lost", false, false},
+		{"pending", 1, "Pending", true, false},
+		{"lost", 1, "Lost", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			won := tt.won
+			fake := &spotNodePoolClient{}
+			fake.pool.Name = "pool"
+			fake.pool.Spec.Desired = 3
+			fake.pool.Status.WonCount = &won
+			fake.pool.Status.BidStatus = tt.bidStatus
+			err := waitForSpotNodePoolFulfillment(fake, "pool", "org")(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var permanent *backoff.PermanentError
+			if errors.As(err, &permanent) != tt.wantPermanent {
+				t.Errorf("error = %v, wantPermanent %v", err, tt.wantPermanent)
+			}
+			if tt.wantPermanent && !errors.Is(err, errBidLost) {
+				t.Errorf("error = %v, want errBidLost", err)
+			}
+			if fake.gets != 1 {
+				t.Errorf("got %d gets of the node pool, want 1", fake.gets)
+			}
+		})
+	}
+}
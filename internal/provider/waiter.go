@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RSS-Engineering/ngpc-cp/pkg/ngpc"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ngpcv1 "github.com/RSS-Engineering/ngpc-cp/api/v1"
+)
+
+const (
+	// waitResyncInterval is the interval at which the condition of a wait is checked
+	// while watching, in case an event is missed.
+	waitResyncInterval = 30 * time.Second
+	// waitJitter is the maximum factor by which the intervals of a wait are randomly extended,
+	// so that waits started together do not query the API at the same time.
+	waitJitter = 0.5
+)
+
+// watchScope describes the objects whose changes trigger a check of the condition of a wait.
+// Name is optional, all the objects of the list type in the namespace are watched without it.
+type watchScope struct {
+	list      client.ObjectList
+	namespace string
+	name      string
+}
+
+func cloudSpaceScope(name string, namespace string) watchScope {
+	return watchScope{list: &ngpcv1.CloudSpaceList{}, namespace: namespace, name: name}
+}
+
+func spotNodePoolScope(name string, namespace string) watchScope {
+	return watchScope{list: &ngpcv1.SpotNodePoolList{}, namespace: namespace, name: name}
+}
+
+func onDemandNodePoolScope(name string, namespace string) watchScope {
+	return watchScope{list: &ngpcv1.OnDemandNodePoolList{}, namespace: namespace, name: name}
+}
+
+// waitCondition checks whether a wait is done. It returns nil when done, a backoff.PermanentError to stop
+// waiting, or an error describing what is still pending. ctx is done when the wait times out.
+type waitCondition func(ctx context.Context) error
+
+// waitFor checks the condition until it returns nil, a permanent error or the timeout elapses.
+// The condition is checked again on every change of the watched objects if the client supports
+// watches, otherwise it is polled with a jittered interval. The wait stops as soon as ctx is canceled.
+// On timeout, the last error of the condition is returned as it describes what is still pending.
+func waitFor(ctx context.Context, c ngpc.Client, timeout time.Duration, condition waitCondition, scopes ...watchScope) error {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var lastErr error
+	check := func() (bool, error) {
+		err := condition(waitCtx)
+		if err == nil {
+			return true, nil
+		}
+		if waitCtx.Err() != nil {
+			// The check was interrupted by the end of the wait, its error does not describe what is pending
+			return false, nil
+		}
+		var permanent *backoff.PermanentError
+		if errors.As(err, &permanent) {
+			return true, permanent.Err
+		}
+		lastErr = err
+		return false, nil
+	}
+	if done, err := check(); done {
+		return err
+	}
+
+	triggers := make(chan struct{}, 1)
+	watching := len(scopes) > 0
+	for _, scope := range scopes {
+		if !watchChanges(waitCtx, c, scope, triggers) {
+			watching = false
+		}
+	}
+	interval := DefaultRefreshInterval
+	if watching {
+		interval = waitResyncInterval
+	}
+	for {
+		timer := time.NewTimer(wait.Jitter(interval, waitJitter))
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			if ctx.Err() != nil {
+				return fmt.Errorf("wait canceled: %w", ctx.Err())
+			}
+			if lastErr != nil {
+				return lastErr
+			}
+			return waitCtx.Err()
+		case <-triggers:
+			timer.Stop()
+		case <-timer.C:
+		}
+		if done, err := check(); done {
+			return err
+		}
+	}
+}
+
+// watchChanges watches the objects of the scope until ctx is done, every event sends a trigger.
+// Watches closed by the server are started again after a jittered delay. It returns false if the
+// client does not support watches or the watch can not be started, the caller has to poll then.
+func watchChanges(ctx context.Context, c ngpc.Client, scope watchScope, triggers chan<- struct{}) bool {
+	watcher, ok := c.(client.WithWatch)
+	if !ok {
+		return false
+	}
+	opts := []client.ListOption{client.InNamespace(scope.namespace)}
+	if scope.name != "" {
+		opts = append(opts, client.MatchingFields{"metadata.name": scope.name})
+	}
+	w, err := watcher.Watch(ctx, scope.list, opts...)
+	if err != nil {
+		tflog.Debug(ctx, "Failed to watch, falling back to polling", map[string]any{
+			"kind":  fmt.Sprintf("%T", scope.list),
+			"name":  scope.name,
+			"error": err.Error(),
+		})
+		return false
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				w.Stop()
+				return
+			case _, open := <-w.ResultChan():
+				if !open {
+					// The delay keeps a watch closed repeatedly by the server from flooding the API
+					timer := time.NewTimer(wait.Jitter(DefaultRefreshInterval, waitJitter))
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+						return
+					case <-timer.C:
+					}
+					w, err = watcher.Watch(ctx, scope.list, opts...)
+					if err != nil {
+						// The resync interval still applies
+						return
+					}
+				}
+				// Events and restarts of the watch, which may have missed changes meanwhile, trigger a check
+				select {
+				case triggers <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return true
+}
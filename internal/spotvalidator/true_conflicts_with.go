@@ -0,0 +1,67 @@
+package spotvalidator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/helpers/validatordiag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ validator.Bool = trueConflictsWithValidator{}
+
+type trueConflictsWithValidator struct {
+	pathExpressions path.Expressions
+}
+
+func (validator trueConflictsWithValidator) Description(_ context.Context) string {
+	return fmt.Sprintf("If the value is true, these must not be true: %q", validator.pathExpressions)
+}
+
+func (validator trueConflictsWithValidator) MarkdownDescription(ctx context.Context) string {
+	return validator.Description(ctx)
+}
+
+func (validator trueConflictsWithValidator) ValidateBool(ctx context.Context, request validator.BoolRequest, response *validator.BoolResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() || !request.ConfigValue.ValueBool() {
+		return
+	}
+
+	for _, expression := range request.PathExpression.MergeExpressions(validator.pathExpressions...) {
+		matchedPaths, diags := request.Config.PathMatches(ctx, expression)
+		response.Diagnostics.Append(diags...)
+		if diags.HasError() {
+			continue
+		}
+
+		for _, matchedPath := range matchedPaths {
+			if matchedPath.Equal(request.Path) {
+				continue
+			}
+
+			var value types.Bool
+			diags := request.Config.GetAttribute(ctx, matchedPath, &value)
+			response.Diagnostics.Append(diags...)
+			if diags.HasError() {
+				continue
+			}
+
+			if value.ValueBool() {
+				response.Diagnostics.Append(validatordiag.InvalidAttributeCombinationDiagnostic(
+					request.Path,
+					fmt.Sprintf("Attribute %q cannot be true when %q is true", matchedPath, request.Path),
+				))
+			}
+		}
+	}
+}
+
+// TrueConflictsWith checks that none of the bool attributes matching the expressions are true
+// when the attribute is true. Unlike boolvalidator.ConflictsWith, false values do not conflict.
+func TrueConflictsWith(expressions ...path.Expression) validator.Bool {
+	return trueConflictsWithValidator{
+		pathExpressions: expressions,
+	}
+}
@@ -0,0 +1,68 @@
+package spotvalidator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestTrueConflictsWith(t *testing.T) {
+	ctx := context.Background()
+	s := schema.Schema{Attributes: map[string]schema.Attribute{
+		"wait_for_fulfillment": schema.BoolAttribute{Optional: true},
+		"wait_for_nodes":       schema.BoolAttribute{Optional: true},
+	}}
+	objectType := tftypes.Object{AttributeTypes: map[string]tftypes.Type{
+		"wait_for_fulfillment": tftypes.Bool,
+		"wait_for_nodes":       tftypes.Bool,
+	}}
+	boolValue := func(value *bool) tftypes.Value {
+		if value == nil {
+			return tftypes.NewValue(tftypes.Bool, nil)
+		}
+		return tftypes.NewValue(tftypes.Bool, *value)
+	}
+	yes, no := true, false
+
+	tests := []struct {
+		name               string
+		waitForFulfillment *bool
+		waitForNodes       *bool
+		wantErr            bool
+	}{
+		{"both true", &yes, &yes, true},
+		{"true and false", &yes, &no, false},
+		{"false and true", &no, &yes, false},
+		{"true and null", &yes, nil, false},
+		{"null and true", nil, &yes, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tfsdk.Config{Schema: s, Raw: tftypes.NewValue(objectType, map[string]tftypes.Value{
+				"wait_for_fulfillment": boolValue(tt.waitForFulfillment),
+				"wait_for_nodes":       boolValue(tt.waitForNodes),
+			})}
+			configValue := types.BoolNull()
+			if tt.waitForFulfillment != nil {
+				configValue = types.BoolValue(*tt.waitForFulfillment)
+			}
+			request := validator.BoolRequest{
+				Config:         config,
+				ConfigValue:    configValue,
+				Path:           path.Root("wait_for_fulfillment"),
+				PathExpression: path.MatchRoot("wait_for_fulfillment"),
+			}
+			response := &validator.BoolResponse{}
+			TrueConflictsWith(path.MatchRoot("wait_for_nodes")).ValidateBool(ctx, request, response)
+			if response.Diagnostics.HasError() != tt.wantErr {
+				t.Errorf("ValidateBool() errors = %v, wantErr %v", response.Diagnostics.Errors(), tt.wantErr)
+			}
+		})
+	}
+}